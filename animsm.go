@@ -0,0 +1,226 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// -------------------------------------------------------------------------------------
+// animation state machine
+//
+//	"animStates": {
+//	  "params": {"jumping": false},
+//	  "states": [
+//	    {"name": "jump", "priority": 3, "when": ["jumping"], "loop": false, "waitEnd": true},
+//	    {"name": "run", "priority": 2, "when": ["moving", "running"]},
+//	    {"name": "walk", "priority": 1, "when": ["moving"]},
+//	    {"name": "idle"}
+//	  ]
+//	}
+//
+// Each frame the state with the highest priority whose conditions are all true
+// becomes the current state, and its animation is played. Built-in conditions
+// are "moving" and "turning"; custom ones are set by SetAnimParam. A condition
+// can be negated by a "!" prefix. "moving" and "turning" stay true for a short
+// grace time after the sprite stops, so scripts that move it once in a few
+// frames don't flip the animation back and forth.
+
+const (
+	AnimParamMoving  = "moving"
+	AnimParamTurning = "turning"
+)
+
+// animStillGrace is how long (in seconds) a sprite must keep still before
+// "moving" or "turning" becomes false.
+const animStillGrace = 0.15
+
+type animStateConfig struct {
+	Name     string   `json:"name"`
+	Anim     string   `json:"anim"`     // animation to play (resolved by animBindings), default is name
+	Priority int      `json:"priority"` // higher priority states are checked first
+	When     []string `json:"when"`     // conditions that must all be true
+	From     []string `json:"from"`     // states that can transit to this state, empty means any
+	Loop     *bool    `json:"loop"`     // default is true
+	WaitEnd  bool     `json:"waitEnd"`  // can't leave this state until its animation ends
+}
+
+type animStatesConfig struct {
+	Params map[string]bool    `json:"params"`
+	States []*animStateConfig `json:"states"`
+}
+
+type animStateMachine struct {
+	states  []*animStateConfig // sorted by priority
+	params  map[string]bool
+	cur     *animStateConfig
+	playing *animState
+
+	lastX, lastY, lastDir float64
+	moving, turning       bool
+	stillMove, stillTurn  float64 // time since last moved or turned
+}
+
+func newAnimStateMachine(p *SpriteImpl, cfg *animStatesConfig) *animStateMachine {
+	states := make([]*animStateConfig, len(cfg.States))
+	copy(states, cfg.States)
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Priority > states[j].Priority
+	})
+	n := 0
+	for _, state := range states {
+		if state.Anim == "" {
+			state.Anim = state.Name
+		}
+		if !p.hasAnim(p.getStateAnimName(state.Anim)) {
+			log.Printf("Warning: animation state [%s] of sprite %s is skipped: animation [%s] not found", state.Name, p.name, state.Anim)
+			continue
+		}
+		states[n] = state
+		n++
+	}
+	states = states[:n]
+	sm := &animStateMachine{states: states, params: maps.Clone(cfg.Params)}
+	if sm.params == nil {
+		sm.params = make(map[string]bool)
+	}
+	sm.lastX, sm.lastY, sm.lastDir = p.x, p.y, p.direction
+	sm.stillMove, sm.stillTurn = animStillGrace, animStillGrace
+	return sm
+}
+
+func (p *animStateMachine) clone(src *SpriteImpl) *animStateMachine {
+	sm := &animStateMachine{states: p.states, params: maps.Clone(p.params)}
+	sm.lastX, sm.lastY, sm.lastDir = src.x, src.y, src.direction
+	sm.stillMove, sm.stillTurn = animStillGrace, animStillGrace
+	return sm
+}
+
+func (p *animStateMachine) reset() {
+	p.cur, p.playing = nil, nil
+}
+
+func (p *animStateMachine) param(name string) bool {
+	switch name {
+	case AnimParamMoving:
+		return p.moving
+	case AnimParamTurning:
+		return p.turning
+	}
+	return p.params[name]
+}
+
+func (p *animStateMachine) match(state *animStateConfig) bool {
+	if len(state.From) > 0 {
+		if p.cur == nil || !slices.Contains(state.From, p.cur.Name) {
+			return false
+		}
+	}
+	for _, cond := range state.When {
+		want := true
+		if name, ok := strings.CutPrefix(cond, "!"); ok {
+			cond, want = name, false
+		}
+		if p.param(cond) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *animStateMachine) choose() *animStateConfig {
+	for _, state := range p.states {
+		if p.match(state) {
+			return state
+		}
+	}
+	return nil
+}
+
+// updateStill accumulates the time a sprite keeps still, and reports whether
+// it's still regarded as changing.
+func updateStill(still *float64, changed bool, delta float64) bool {
+	if changed {
+		*still = 0
+		return true
+	}
+	*still += delta
+	return *still < animStillGrace
+}
+
+func isAnimRunning(info *animState) bool {
+	return info != nil && !info.IsDone && !info.IsCanceled
+}
+
+// updateAnimStates is called every frame to switch animations of the state machine.
+func (p *SpriteImpl) updateAnimStates(delta float64) {
+	sm := p.animSM
+	if sm == nil {
+		return
+	}
+	sm.moving = updateStill(&sm.stillMove, p.x != sm.lastX || p.y != sm.lastY, delta)
+	sm.turning = updateStill(&sm.stillTurn, p.direction != sm.lastDir, delta)
+	sm.lastX, sm.lastY, sm.lastDir = p.x, p.y, p.direction
+	if !p.isVisible || p.isDying {
+		return
+	}
+	if cur := p.curAnimState; cur != sm.playing && isAnimRunning(cur) {
+		return // animations started by scripts take precedence
+	}
+	if sm.cur != nil && sm.cur.WaitEnd && isAnimRunning(sm.playing) {
+		return
+	}
+	next := sm.choose()
+	if next == nil || (next == sm.cur && sm.playing != nil && !sm.playing.IsCanceled) {
+		return
+	}
+	ani := *p.animations[p.getStateAnimName(next.Anim)]
+	ani.IsLoop = next.Loop == nil || *next.Loop
+	ani.IsKeepOnStop = true
+	sm.cur = next
+	sm.playing = p.goAnimateInternal(p.getStateAnimName(next.Anim), &ani, false)
+}
+
+// SetAnimParam sets a custom condition of the animation state machine.
+func (p *SpriteImpl) SetAnimParam(name string, val bool) {
+	if p.animSM == nil {
+		return
+	}
+	p.animSM.params[name] = val
+}
+
+// AnimParam returns a condition of the animation state machine.
+func (p *SpriteImpl) AnimParam(name string) bool {
+	if p.animSM == nil {
+		return false
+	}
+	return p.animSM.param(name)
+}
+
+// AnimState returns the current state of the animation state machine.
+func (p *SpriteImpl) AnimState() string {
+	if p.animSM == nil || p.animSM.cur == nil {
+		return ""
+	}
+	return p.animSM.cur.Name
+}
+
+// -------------------------------------------------------------------------------------
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/goplus/spx/v2/internal/ui"
+)
+
+// -------------------------------------------------------------------------------------
+// ask: Ask shows a question and waits for a free-text answer. AskChoice and
+// AskNumber validate answers, and ask again with a hint until a valid one is
+// given. Each of them has a variant with a timeout in seconds. Questions and
+// their answers are kept in AskHistory, so that quizzes can be built directly.
+
+// An AskRecord is a question and its answer.
+type AskRecord struct {
+	Question string  // the question without options and hints
+	Answer   string  // the last answer, empty if it's timed out
+	Choice   int     // index of the chosen option of AskChoice, -1 for others
+	Number   float64 // the number answered to AskNumber
+	Tries    int     // how many answers are given, invalid ones included
+	Secs     float64 // time used to answer
+	TimedOut bool
+}
+
+func (p *Game) Ask__0(msg any) {
+	p.Ask__1(msg, 0)
+}
+
+// Ask__1 asks a question, and waits for an answer up to secs seconds (no limit
+// if secs <= 0). It reports whether the question is answered in time.
+func (p *Game) Ask__1(msg any, secs float64) bool {
+	if debugInstr {
+		log.Println("Ask", msg, secs)
+	}
+	return p.askText(nil, msg, secs)
+}
+
+func (p *Game) AskChoice__0(question string, options ...string) int {
+	return p.AskChoice__1(question, 0, options...)
+}
+
+// AskChoice__1 asks a question with options, which can be answered by the
+// number (from 1) or the text of an option. It returns the index (from 0) of
+// the chosen option, or -1 if it isn't answered in secs seconds.
+func (p *Game) AskChoice__1(question string, secs float64, options ...string) int {
+	if debugInstr {
+		log.Println("AskChoice", question, options, secs)
+	}
+	return p.askChoice(nil, question, secs, options)
+}
+
+func (p *Game) AskNumber__0(question string, low, high float64) float64 {
+	v, _ := p.AskNumber__1(question, low, high, 0)
+	return v
+}
+
+// AskNumber__1 asks for a number in range [low, high]. It returns low and false
+// if the question isn't answered in secs seconds.
+func (p *Game) AskNumber__1(question string, low, high, secs float64) (float64, bool) {
+	if debugInstr {
+		log.Println("AskNumber", question, low, high, secs)
+	}
+	return p.askNumber(nil, question, low, high, secs)
+}
+
+// Answer returns the last answer, it's empty if the last question is timed out.
+func (p *Game) Answer() string {
+	return p.answerVal
+}
+
+// AskHistory returns all questions asked and their answers, from the oldest
+// to the latest.
+func (p *Game) AskHistory() []AskRecord {
+	return append([]AskRecord(nil), p.askHistory...)
+}
+
+// ClearAskHistory clears the history of questions, eg. when a quiz restarts.
+func (p *Game) ClearAskHistory() {
+	p.askHistory = nil
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *Game) askText(spr *SpriteImpl, msg any, secs float64) bool {
+	question, ok := msg.(string)
+	if !ok {
+		question = fmt.Sprint(msg)
+	}
+	if question == "" {
+		log.Println("ask: msg should not be empty")
+		return false
+	}
+	rec := p.askUntil(spr, question, tr(question), secs, func(answer string, rec *AskRecord) (string, bool) {
+		return "", true
+	})
+	p.askHistory = append(p.askHistory, rec)
+	return !rec.TimedOut
+}
+
+func (p *Game) askChoice(spr *SpriteImpl, question string, secs float64, options []string) int {
+	if len(options) == 0 {
+		log.Println("askChoice: options should not be empty")
+		return -1
+	}
+	rec := p.pickChoice(spr, question, secs, options)
+	p.askHistory = append(p.askHistory, rec)
+	return rec.Choice
+}
+
+// pickChoice asks to choose one of options like askChoice, but the record isn't
+// added to the history, eg. for choices of a dialogue.
+func (p *Game) pickChoice(spr *SpriteImpl, question string, secs float64, options []string) AskRecord {
+	var b strings.Builder
+	b.WriteString(tr(question))
+	for i, opt := range options {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, tr(opt))
+	}
+	return p.askUntil(spr, question, b.String(), secs, func(answer string, rec *AskRecord) (string, bool) {
+		answer = strings.TrimSpace(answer)
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			rec.Choice = n - 1
+			return "", true
+		}
+		for i, opt := range options {
+			if strings.EqualFold(answer, opt) || strings.EqualFold(answer, tr(opt)) {
+				rec.Choice = i
+				return "", true
+			}
+		}
+		return T("Please choose from 1 to %d", len(options)), false
+	})
+}
+
+func (p *Game) askNumber(spr *SpriteImpl, question string, low, high, secs float64) (float64, bool) {
+	rec := p.askUntil(spr, question, tr(question), secs, func(answer string, rec *AskRecord) (string, bool) {
+		n, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+		if err != nil || n < low || n > high {
+			return T("Please enter a number from %v to %v", low, high), false
+		}
+		rec.Number = n
+		return "", true
+	})
+	p.askHistory = append(p.askHistory, rec)
+	if rec.TimedOut {
+		return low, false
+	}
+	return rec.Number, true
+}
+
+// askUntil shows prompt and waits for answers until check accepts one or it's
+// timed out, check returns a hint to be shown with prompt for an invalid answer,
+// and completes rec for a valid one. The question is shown by the sprite if spr
+// isn't nil. The record isn't added to the history, it's up to the caller.
+func (p *Game) askUntil(
+	spr *SpriteImpl, question, prompt string, secs float64,
+	check func(answer string, rec *AskRecord) (hint string, ok bool)) AskRecord {
+	rec := AskRecord{Question: question, Choice: -1}
+	text := prompt
+	for {
+		if spr != nil {
+			spr.sayOrThink(text, styleSay)
+		}
+		remain := 0.0
+		if secs > 0 {
+			remain = max(secs-rec.Secs, 0.001)
+		}
+		answer, elapsed, ok := p.waitAnswer(spr != nil, text, remain)
+		rec.Secs += elapsed
+		p.answerVal = answer
+		if !ok {
+			rec.Answer, rec.TimedOut = "", true
+			break
+		}
+		rec.Answer = answer
+		rec.Tries++
+		hint, valid := check(answer, &rec)
+		if valid {
+			break
+		}
+		text = hint + "\n" + prompt
+	}
+	if spr != nil {
+		spr.doStopSay()
+	}
+	return rec
+}
+
+// waitAnswer shows the ask panel and waits for an answer up to secs seconds (no
+// limit if secs <= 0). ok is false if it's timed out. Asks of different scripts
+// are serialized: the panel is shown after the pending ask is done, and the time
+// waiting for it counts.
+func (p *Game) waitAnswer(isSprite bool, question string, secs float64) (answer string, elapsed float64, ok bool) {
+	panel, elapsed := p.lockAskPanel(secs)
+	if panel == nil {
+		return "", elapsed, false
+	}
+	defer func() { // also runs if the script is aborted
+		if !ok {
+			panel.Hide()
+		}
+		p.unlockAskPanel(panel)
+	}()
+	panel.Show(isSprite, question, func(msg string) {
+		answer, ok = msg, true
+	})
+	for !ok {
+		if secs > 0 && elapsed >= secs {
+			return "", elapsed, false
+		}
+		elapsed += engine.WaitNextFrame()
+	}
+	return
+}
+
+// showStageText shows msg by the ask panel without an input box for secs
+// seconds, eg. narration of a dialogue. It waits for the pending ask first.
+func (p *Game) showStageText(msg string, secs float64) {
+	panel, _ := p.lockAskPanel(0)
+	defer func() {
+		panel.Hide()
+		p.unlockAskPanel(panel)
+	}()
+	panel.ShowText(msg)
+	engine.Wait(secs)
+}
+
+// lockAskPanel waits until the ask panel is free, up to secs seconds (no limit
+// if secs <= 0), and takes it. It returns nil if it's timed out.
+func (p *Game) lockAskPanel(secs float64) (panel *ui.UiAsk, elapsed float64) {
+	for p.askBusy {
+		if secs > 0 && elapsed >= secs {
+			return nil, elapsed
+		}
+		elapsed += engine.WaitNextFrame()
+	}
+	if p.askPanel == nil {
+		p.askPanel = ui.NewUiAsk()
+		p.addShape(p.askPanel)
+	}
+	p.askBusy = true
+	return p.askPanel, elapsed
+}
+
+func (p *Game) unlockAskPanel(panel *ui.UiAsk) {
+	if p.askPanel == panel { // not reset by a restart
+		p.askBusy = false
+	}
+}
+
+// -------------------------------------------------------------------------------------
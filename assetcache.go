@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	iofs "io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	spxfs "github.com/goplus/spx/v2/fs"
+	"github.com/goplus/spx/v2/internal/engine"
+)
+
+// -------------------------------------------------------------------------------------
+// asset cache: the engine loads images, sounds and fonts from a local directory.
+// If assets are not in one (eg. embedded in the binary, or on a HTTP server),
+// each asset is copied from the fs.Dir into a cache directory when the engine
+// uses it first. It's not supported on web and in pack mode.
+
+type assetCache struct {
+	fs   spxfs.Dir
+	dir  string
+	mu   sync.Mutex
+	done map[string]bool
+}
+
+// useAssetCache makes the engine load assets of fs through an asset cache if
+// fs isn't a local directory. key identifies the resource in the cache.
+func useAssetCache(fs spxfs.Dir, key string) *assetCache {
+	if _, ok := fs.(spxfs.GdDir); ok {
+		return nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		log.Println("Warning: assets are only read by spx, no cache directory -", err)
+		return nil
+	}
+	sum := sha1.Sum([]byte(key))
+	p := &assetCache{
+		fs:   fs,
+		dir:  filepath.Join(base, "spx", "assets", hex.EncodeToString(sum[:8])),
+		done: make(map[string]bool),
+	}
+	if !engine.SetAssetCache(p.dir, p.fetch) {
+		log.Println("Warning: assets are only read by spx, the engine can't load them on this platform")
+		return nil
+	}
+	return p
+}
+
+// fetch copies an asset into the cache directory if it's changed, it's called
+// by the engine package before the path of the asset is used.
+func (p *assetCache) fetch(file string) {
+	file = path.Clean(file)
+	if !iofs.ValidPath(file) || file == "." {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done[file] {
+		return
+	}
+	p.done[file] = true
+	if err := p.copy(file); err != nil && debugLoad {
+		log.Println("assetCache: can't fetch", file, "-", err)
+	}
+}
+
+func (p *assetCache) copy(file string) error {
+	f, err := p.fs.Open(file)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	local := filepath.Join(p.dir, filepath.FromSlash(file))
+	if old, err := os.ReadFile(local); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return err
+	}
+	tmp := local + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, local)
+}
+
+// -------------------------------------------------------------------------------------
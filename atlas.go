@@ -0,0 +1,267 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/realdream-ai/mathf"
+
+	spxfs "github.com/goplus/spx/v2/fs"
+)
+
+// -------------------------------------------------------------------------------------
+// costumeAtlas: costumes from a TexturePacker or Aseprite JSON data file.
+//
+// Both formats share the same frame layout:
+//
+//	{
+//	  "frames": {"name": {"frame": {...}, "spriteSourceSize": {...}, "sourceSize": {...}, "pivot": {...}, "duration": 100}, ...},
+//	  "meta": {"image": "hero.png", "size": {"w": 256, "h": 256}, "frameTags": [{"name": "walk", "from": 0, "to": 3, "direction": "forward"}]}
+//	}
+//
+// "frames" can also be an array of frames with a "filename" field.
+
+type atlasRect struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+type atlasSize struct {
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+type atlasFrame struct {
+	Filename         string      `json:"filename"`
+	Frame            atlasRect   `json:"frame"`
+	Rotated          bool        `json:"rotated"`
+	Trimmed          bool        `json:"trimmed"`
+	SpriteSourceSize atlasRect   `json:"spriteSourceSize"`
+	SourceSize       atlasSize   `json:"sourceSize"`
+	Pivot            *mathf.Vec2 `json:"pivot"`    // normalized, TexturePacker only
+	Duration         float64     `json:"duration"` // in milliseconds, Aseprite only
+}
+
+type atlasFrameTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"` // forward, reverse, pingpong or pingpong_reverse
+}
+
+type atlasMeta struct {
+	Image     string          `json:"image"`
+	Size      atlasSize       `json:"size"`
+	FrameTags []atlasFrameTag `json:"frameTags"`
+}
+
+type atlasData struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   atlasMeta       `json:"meta"`
+}
+
+// loadCostumeAtlas loads the data file of a costumeAtlas config.
+func loadCostumeAtlas(fs spxfs.Dir, base string, atlas *costumeAtlas) (err error) {
+	dataPath := path.Join(base, atlas.Path)
+	var data atlasData
+	if err = loadJson(&data, fs, dataPath); err != nil {
+		return
+	}
+	frames, err := decodeAtlasFrames(data.Frames)
+	if err != nil {
+		return fmt.Errorf("costumeAtlas %s: %w", atlas.Path, err)
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("costumeAtlas %s: no frames", atlas.Path)
+	}
+	image := atlas.Image
+	if image == "" {
+		image = data.Meta.Image
+	}
+	if image == "" {
+		return fmt.Errorf("costumeAtlas %s: image not specified", atlas.Path)
+	}
+	for i := range frames {
+		if frames[i].Rotated {
+			return fmt.Errorf("costumeAtlas %s: frame %s: rotated frames are not supported", atlas.Path, frames[i].Filename)
+		}
+	}
+	for _, tag := range data.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(frames) || tag.From > tag.To {
+			return fmt.Errorf("costumeAtlas %s: frame tag [%s] is out of range", atlas.Path, tag.Name)
+		}
+		switch tag.Direction {
+		case "", "forward", "reverse", "pingpong", "pingpong_reverse":
+		default:
+			return fmt.Errorf("costumeAtlas %s: frame tag [%s]: unknown direction %q", atlas.Path, tag.Name, tag.Direction)
+		}
+	}
+	atlas.imagePath = path.Join(path.Dir(dataPath), image)
+	atlas.imageSize = mathf.NewVec2(data.Meta.Size.W, data.Meta.Size.H)
+	atlas.frames = frames
+	atlas.frameTags = data.Meta.FrameTags
+	return
+}
+
+// decodeAtlasFrames decodes frames in hash or array form. The order of frames
+// in the hash form is kept, since frame tags refer to frames by index.
+func decodeAtlasFrames(raw json.RawMessage) (frames []atlasFrame, err error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return
+	}
+	if raw[0] == '[' {
+		err = json.Unmarshal(raw, &frames)
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err = dec.Token(); err != nil { // {
+		return
+	}
+	for dec.More() {
+		tok, e := dec.Token()
+		if e != nil {
+			return nil, e
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("invalid frame name")
+		}
+		var frame atlasFrame
+		if err = dec.Decode(&frame); err != nil {
+			return
+		}
+		if frame.Filename == "" {
+			frame.Filename = name
+		}
+		frames = append(frames, frame)
+	}
+	return
+}
+
+// atlasCostumeName strips the file extension of a frame name.
+func atlasCostumeName(filename string) SpriteCostumeName {
+	if ext := path.Ext(filename); ext != "" && !strings.ContainsAny(ext, " ") {
+		return strings.TrimSuffix(filename, ext)
+	}
+	return filename
+}
+
+func newCostumeWithAtlas(atlas *costumeAtlas, frame *atlasFrame, i int) *costume {
+	value := &costume{
+		path: atlas.imagePath,
+		name: atlasCostumeName(frame.Filename), setIndex: i,
+		faceRight: atlas.FaceRight, bitmapResolution: toBitmapResolution(atlas.BitmapResolution),
+	}
+	imageSize := atlas.imageSize
+	if imageSize.X == 0 || imageSize.Y == 0 {
+		imageSize = getCustomeAssetSize(atlas.imagePath)
+	}
+	value.imageSize = imageSize
+	value.posX, value.posY = int(frame.Frame.X), int(frame.Frame.Y)
+	value.width, value.height = int(frame.Frame.W), int(frame.Frame.H)
+
+	// calc atlas uv
+	uStart := float64(value.posX) / imageSize.X
+	vStart := float64(value.posY) / imageSize.Y
+	uSize := float64(value.width) / imageSize.X
+	vSize := float64(value.height) / imageSize.Y
+	value.altasUVRect = mathf.NewVec4(uStart, vStart, uSize, vSize)
+
+	// the pivot is relative to the untrimmed source image, while center is
+	// relative to the trimmed frame
+	srcW, srcH := frame.SourceSize.W, frame.SourceSize.H
+	if srcW == 0 || srcH == 0 {
+		srcW, srcH = frame.Frame.W, frame.Frame.H
+	}
+	pivot := mathf.NewVec2(0.5, 0.5)
+	if frame.Pivot != nil {
+		pivot = *frame.Pivot
+	}
+	value.center.X = pivot.X*srcW - frame.SpriteSourceSize.X
+	value.center.Y = pivot.Y*srcH - frame.SpriteSourceSize.Y
+	return value
+}
+
+func initWithAtlas(p *baseObj, atlas *costumeAtlas) {
+	p.isCostumeSet = true
+	p.costumes = make([]*costume, 0, len(atlas.frames))
+	for i := range atlas.frames {
+		p.costumes = append(p.costumes, newCostumeWithAtlas(atlas, &atlas.frames[i], i))
+	}
+}
+
+// atlasAnimations creates frame animations from Aseprite frame tags, which are
+// checked by loadCostumeAtlas. Engine animations play at a constant fps, so the
+// average duration of the frames in a tag is used.
+func atlasAnimations(atlas *costumeAtlas) map[string]*aniConfig {
+	anims := make(map[string]*aniConfig, len(atlas.frameTags))
+	for _, tag := range atlas.frameTags {
+		total := 0.0
+		for i := tag.From; i <= tag.To; i++ {
+			total += atlas.frames[i].Duration
+		}
+		ani := &aniConfig{}
+		if total > 0 {
+			ani.FrameFps = max(1, int(float64(tag.To-tag.From+1)*1000/total+0.5))
+		}
+		from, to := float64(tag.From), float64(tag.To)
+		if tag.Direction == "reverse" || tag.Direction == "pingpong_reverse" {
+			from, to = to, from
+		}
+		ani.FrameFrom, ani.FrameTo = from, to
+		if strings.HasPrefix(tag.Direction, "pingpong") {
+			ani.frames = pingpongFrames(int(from), int(to))
+		}
+		anims[tag.Name] = ani
+	}
+	return anims
+}
+
+// pingpongFrames returns frames from `from` to `to` and back to `from`.
+func pingpongFrames(from, to int) []int {
+	frames := framesBetween(from, to)
+	for i := len(frames) - 2; i >= 0; i-- {
+		frames = append(frames, frames[i])
+	}
+	return frames
+}
+
+// framesBetween returns frames from `from` to `to`, both are included.
+func framesBetween(from, to int) []int {
+	frames := make([]int, 0, max(from-to, to-from)+1)
+	if from <= to {
+		for i := from; i <= to; i++ {
+			frames = append(frames, i)
+		}
+	} else {
+		for i := from; i >= to; i-- {
+			frames = append(frames, i)
+		}
+	}
+	return frames
+}
+
+// -------------------------------------------------------------------------------------
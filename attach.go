@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"log"
+	"math"
+	"slices"
+	"sort"
+)
+
+// -------------------------------------------------------------------------------------
+// child attachments: a child sprite follows the position, heading and size of
+// its parent.
+
+// AttachOptions are options of Attach.
+type AttachOptions struct {
+	X, Y    float64 // position relative to the parent when the parent faces right with size 1
+	Heading float64 // heading relative to the parent
+	Size    float64 // size relative to the parent, 0 means keeping the current ratio
+	ZOrder  int     // render above the parent if >= 0, or below it if < 0
+}
+
+type attachInfo struct {
+	parent *SpriteImpl
+	AttachOptions
+}
+
+func (p *SpriteImpl) Attach__0(child Sprite, x, y float64) {
+	p.Attach__1(child, &AttachOptions{X: x, Y: y})
+}
+
+// Attach__1 attaches child to this sprite, so the child follows this sprite's
+// position, heading and size.
+func (p *SpriteImpl) Attach__1(child Sprite, options *AttachOptions) {
+	c := spriteOf(child)
+	if c == nil || c == p || options == nil {
+		return
+	}
+	for parent := p; parent != nil; parent = parent.attachParent() {
+		if parent == c {
+			log.Println("Attach: can't attach an ancestor", c.name, "to", p.name)
+			return
+		}
+	}
+	if debugInstr {
+		log.Println("Attach", c.name, "to", p.name, *options)
+	}
+	c.Detach()
+	opts := *options
+	if opts.Size == 0 {
+		opts.Size = 1
+		if p.scale != 0 {
+			opts.Size = c.scale / p.scale
+		}
+	}
+	c.attach = &attachInfo{parent: p, AttachOptions: opts}
+	p.children = append(p.children, c)
+	c.updateAttachTransform()
+	p.g.updateRenderLayers()
+}
+
+// Detach detaches this sprite from its parent. The sprite keeps its current
+// position, heading and size.
+func (p *SpriteImpl) Detach() {
+	if p.attach == nil {
+		return
+	}
+	parent := p.attach.parent
+	parent.children = slices.DeleteFunc(parent.children, func(c *SpriteImpl) bool {
+		return c == p
+	})
+	p.attach = nil
+	p.g.updateRenderLayers()
+}
+
+// AttachParent returns the sprite this sprite is attached to, or nil.
+func (p *SpriteImpl) AttachParent() Sprite {
+	if parent := p.attachParent(); parent != nil {
+		return parent.sprite
+	}
+	return nil
+}
+
+// Children returns sprites attached to this sprite.
+func (p *SpriteImpl) Children() []Sprite {
+	ret := make([]Sprite, len(p.children))
+	for i, c := range p.children {
+		ret[i] = c.sprite
+	}
+	return ret
+}
+
+func (p *SpriteImpl) attachParent() *SpriteImpl {
+	if p.attach == nil {
+		return nil
+	}
+	return p.attach.parent
+}
+
+// detachAll detaches this sprite from its parent and all its children.
+func (p *SpriteImpl) detachAll() {
+	p.Detach()
+	for _, c := range p.children {
+		c.attach = nil
+	}
+	if p.children != nil {
+		p.children = nil
+		p.g.updateRenderLayers()
+	}
+}
+
+// updateAttachTransform computes the world transform of an attached sprite
+// from the transform of its parent.
+func (p *SpriteImpl) updateAttachTransform() {
+	info := p.attach
+	parent := info.parent
+	x, y := info.X, info.Y
+	switch parent.rotationStyle {
+	case Normal:
+		sin, cos := math.Sincos(toRadian(parent.direction - 90))
+		x, y = x*cos+y*sin, -x*sin+y*cos
+	case LeftRight:
+		if parent.direction < 0 {
+			x = -x
+		}
+	}
+	scale := parent.scale * info.Size
+	p.x, p.y = parent.x+x*parent.scale, parent.y+y*parent.scale
+	p.direction = normalizeDirection(parent.direction + info.Heading)
+	if p.scale != scale {
+		p.scale = scale
+		p.isCostumeDirty = true
+	}
+	p.updateTransform()
+}
+
+func (p *SpriteImpl) updateChildren() {
+	for _, c := range p.children {
+		c.updateAttachTransform()
+	}
+}
+
+// appendRenderOrder appends this sprite and its children to items, children
+// with negative ZOrder are rendered below this sprite.
+func (p *SpriteImpl) appendRenderOrder(items []*SpriteImpl) []*SpriteImpl {
+	children := slices.Clone(p.children)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].attach.ZOrder < children[j].attach.ZOrder
+	})
+	i := 0
+	for ; i < len(children) && children[i].attach.ZOrder < 0; i++ {
+		items = children[i].appendRenderOrder(items)
+	}
+	items = append(items, p)
+	for ; i < len(children); i++ {
+		items = children[i].appendRenderOrder(items)
+	}
+	return items
+}
+
+// -------------------------------------------------------------------------------------
@@ -0,0 +1,731 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	spxfs "github.com/goplus/spx/v2/fs"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// project checker: validates index.json, scenes/*.json, dialogues/*.json and
+// sprites/*/index.json against projConfig, dialogueConfig and spriteConfig, and
+// checks references between them (costume paths, animation frames, zorder
+// entries, dialogue nodes, etc).
+
+// CheckError is a problem found in a project file.
+type CheckError struct {
+	File string // file path relative to the assets directory
+	Path string // JSON path, eg. $.fAnimations.walk.frameFrom
+	Msg  string
+}
+
+func (p *CheckError) Error() string {
+	return p.File + ": " + p.Path + ": " + p.Msg
+}
+
+// CheckProject checks the project whose assets directory is dir, and returns
+// all problems found. It doesn't need the engine.
+func CheckProject(dir string) ([]*CheckError, error) {
+	fs, err := spxfs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	sprites, err := listSprites(fs)
+	if err != nil {
+		return nil, err
+	}
+	scenes, err := listJSON(fs, "scenes")
+	if err != nil {
+		return nil, err
+	}
+	dialogues, err := listJSON(fs, "dialogues")
+	if err != nil {
+		return nil, err
+	}
+	return checkProject(fs, "index.json", scenes, dialogues, sprites), nil
+}
+
+// listSprites returns names of sprites in the sprites directory of fs.
+func listSprites(fs spxfs.Dir) ([]string, error) {
+	entries, err := spxfs.ReadDir(fs, "sprites")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var sprites []string
+	for _, e := range entries {
+		if e.IsDir() {
+			sprites = append(sprites, e.Name())
+		}
+	}
+	return sprites, nil
+}
+
+// listJSON returns names (without the .json extension) of JSON files in dir of
+// fs, eg. scenes.
+func listJSON(fs spxfs.Dir, dir string) ([]string, error) {
+	entries, err := spxfs.ReadDir(fs, dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".json") {
+			names = append(names, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	return names, nil
+}
+
+func checkProject(fs spxfs.Dir, index string, scenes, dialogues, sprites []string) []*CheckError {
+	c := &checker{fs: fs, sprites: make(map[string]bool, len(sprites))}
+	for _, name := range sprites {
+		c.sprites[name] = true
+	}
+	var proj projConfig
+	if c.loadFile(index, &proj) {
+		c.checkProj(&proj)
+	}
+	for _, name := range scenes {
+		var scene projConfig
+		if c.loadFile(sceneFile(name), &scene) {
+			c.checkProj(&scene)
+		}
+	}
+	for _, name := range dialogues {
+		var d dialogueConfig
+		if c.loadFile(dialogueFile(name), &d) {
+			c.checkDialogue(&d)
+		}
+	}
+	for _, name := range sprites {
+		var conf spriteConfig
+		base := "sprites/" + name + "/"
+		if c.loadFile(base+"index.json", &conf) {
+			c.checkSprite(base, &conf)
+		}
+	}
+	return c.errs
+}
+
+type checker struct {
+	fs      spxfs.Dir
+	file    string
+	sprites map[string]bool
+	errs    []*CheckError
+}
+
+func (p *checker) errorf(path string, format string, args ...any) {
+	p.errs = append(p.errs, &CheckError{File: p.file, Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// loadFile checks file against the type of ret, and then decodes it into ret.
+func (p *checker) loadFile(file string, ret any) bool {
+	p.file = file
+	data, err := readFile(p.fs, file)
+	if err != nil {
+		p.errorf("$", "can't read file: %v", err)
+		return false
+	}
+	var doc any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		if e, ok := err.(*json.SyntaxError); ok {
+			line, col := lineCol(data, e.Offset)
+			p.errorf("$", "syntax error at line %d, column %d: %v", line, col, err)
+		} else {
+			p.errorf("$", "%v", err)
+		}
+		return false
+	}
+	p.checkValue("$", doc, reflect.TypeOf(ret).Elem())
+	json.Unmarshal(data, ret) // type errors are reported by checkValue
+	return true
+}
+
+func lineCol(data []byte, offset int64) (line, col int) {
+	data = data[:min(int(offset), len(data))]
+	line = bytes.Count(data, []byte{'\n'}) + 1
+	col = len(data) - bytes.LastIndexByte(data, '\n')
+	return
+}
+
+func jsonPathKey(path, key string) string {
+	for _, c := range key {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return path + "." + key
+}
+
+func jsonPathIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+var tyJsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// checkValue checks v (decoded from JSON) against how encoding/json decodes
+// it into type t, unknown object fields are reported too.
+func (p *checker) checkValue(path string, v any, t reflect.Type) {
+	if v == nil {
+		return
+	}
+	if _, ok := v.(map[string]any); !ok && reflect.PointerTo(t).Implements(tyJsonUnmarshaler) {
+		// shorthand of an object, eg. actionConfig
+		data, _ := json.Marshal(v)
+		if err := json.Unmarshal(data, reflect.New(t).Interface()); err != nil {
+			p.errorf(path, "invalid value %s", jsonText(v))
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		p.checkValue(path, v, t.Elem())
+	case reflect.Interface:
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			p.errorf(path, "want boolean, got %s", jsonText(v))
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			p.errorf(path, "want string, got %s", jsonText(v))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := v.(float64); !ok || f != math.Trunc(f) {
+			p.errorf(path, "want integer, got %s", jsonText(v))
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(float64); !ok {
+			p.errorf(path, "want number, got %s", jsonText(v))
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]any)
+		if !ok {
+			p.errorf(path, "want array, got %s", jsonText(v))
+			return
+		}
+		for i, item := range items {
+			p.checkValue(jsonPathIndex(path, i), item, t.Elem())
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			p.errorf(path, "want object, got %s", jsonText(v))
+			return
+		}
+		for _, key := range sortedKeys(obj) {
+			p.checkValue(jsonPathKey(path, key), obj[key], t.Elem())
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			p.errorf(path, "want object, got %s", jsonText(v))
+			return
+		}
+		fields := jsonFields(t, nil)
+		for _, key := range sortedKeys(obj) {
+			ft, ok := fields[key]
+			if !ok {
+				ft, ok = fields[strings.ToLower(key)] // encoding/json matches keys case-insensitively
+			}
+			if !ok {
+				p.errorf(jsonPathKey(path, key), "unknown field %q", key)
+				continue
+			}
+			p.checkValue(jsonPathKey(path, key), obj[key], ft)
+		}
+	}
+}
+
+// jsonFields returns JSON keys of fields of struct type t, both in original
+// and lower case.
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) map[string]reflect.Type {
+	if fields == nil {
+		fields = make(map[string]reflect.Type)
+	}
+	for i, n := 0, t.NumField(); i < n; i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			jsonFields(f.Type, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonText(v any) string {
+	data, _ := json.Marshal(v)
+	if len(data) > 32 {
+		return string(data[:29]) + "..."
+	}
+	return string(data)
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *checker) checkPath(path, file string) {
+	if file == "" {
+		p.errorf(path, "path is required")
+	} else if !hasFile(p.fs, file) {
+		p.errorf(path, "file not found: %s", file)
+	}
+}
+
+func (p *checker) checkShader(path string, cfg *shaderConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Path != "" {
+		p.checkPath(path+".path", cfg.Path)
+	}
+	for _, name := range sortedKeys(cfg.Params) {
+		if _, ok := toShaderParam(cfg.Params[name]); !ok {
+			p.errorf(jsonPathKey(path+".params", name), "want number or array of up to 4 numbers")
+		}
+	}
+}
+
+func (p *checker) checkProj(proj *projConfig) {
+	key := "backdrops"
+	if proj.Backdrops == nil {
+		if proj.Scenes != nil {
+			key = "scenes"
+		} else {
+			key = "costumes"
+		}
+	}
+	backdrops := proj.getBackdrops()
+	for i, c := range backdrops {
+		if c != nil {
+			p.checkPath(jsonPathIndex("$."+key, i)+".path", c.Path)
+		}
+	}
+	if idx := proj.getBackdropIndex(); idx != 0 && (idx < 0 || idx >= len(backdrops)) {
+		p.errorf("$.backdropIndex", "index %d out of range [0, %d)", idx, len(backdrops))
+	}
+	switch proj.Map.Mode {
+	case "", "fill", "repeat", "fillRatio", "fillCut":
+	default:
+		p.errorf("$.map.mode", "unknown mode %q", proj.Map.Mode)
+	}
+	p.checkShader("$.shader", proj.Shader)
+	if proj.Font != "" {
+		p.checkPath("$.font", proj.Font)
+	}
+	for i, tm := range proj.Tilemaps {
+		if tm != nil {
+			p.checkTilemap(jsonPathIndex("$.tilemaps", i), tm)
+		}
+	}
+	for i, v := range proj.Zorder {
+		path := jsonPathIndex("$.zorder", i)
+		switch v := v.(type) {
+		case string:
+			if !p.sprites[v] {
+				p.errorf(path, "sprite %q not found", v)
+			}
+		case map[string]any:
+			p.checkSpecialShape(path, v)
+		default:
+			p.errorf(path, "want sprite name or object, got %s", jsonText(v))
+		}
+	}
+}
+
+func (p *checker) checkTilemap(path string, tm *tilemapConfig) {
+	if tm.Path != "" {
+		layers, err := loadTiledMap(p.fs, tm.Path)
+		if err != nil {
+			p.errorf(path+".path", "%v", err)
+		} else if len(layers) > 0 {
+			for _, ts := range layers[0].tilesets {
+				p.checkPath(path+".path", ts.path)
+			}
+		}
+		return
+	}
+	if ts := tm.Tileset; ts == nil {
+		p.errorf(path+".tileset", "tileset or path is required")
+	} else {
+		p.checkPath(path+".tileset.path", ts.Path)
+		if ts.TileWidth <= 0 || ts.TileHeight <= 0 {
+			p.errorf(path+".tileset", "tileWidth and tileHeight must be positive")
+		}
+		if ts.Columns <= 0 {
+			p.errorf(path+".tileset.columns", "columns must be positive")
+		}
+	}
+	if n := tm.Width * tm.Height; n <= 0 || len(tm.Data) != n {
+		p.errorf(path+".data", "want %d x %d tiles, got %d", tm.Width, tm.Height, len(tm.Data))
+	}
+}
+
+func (p *checker) checkDialogue(d *dialogueConfig) {
+	checkNext := func(path, next string) {
+		if next != "" && d.Nodes[next] == nil {
+			p.errorf(path, "node %q not found", next)
+		}
+	}
+	checkCond := func(path, cond string) {
+		if cond == "" {
+			return
+		}
+		if c, err := parseDialogueCond(cond); err != nil {
+			p.errorf(path, "invalid condition %q: %v", cond, err)
+		} else if c.target != "" && !p.sprites[c.target] {
+			p.errorf(path, "sprite %q not found", c.target)
+		}
+	}
+	if d.Start == "" {
+		p.errorf("$.start", "start is required")
+	} else {
+		checkNext("$.start", d.Start)
+	}
+	for _, id := range sortedKeys(d.Nodes) {
+		node, path := d.Nodes[id], jsonPathKey("$.nodes", id)
+		if node == nil {
+			p.errorf(path, "want object, got null")
+			continue
+		}
+		if node.Speaker != "" && !p.sprites[node.Speaker] {
+			p.errorf(path+".speaker", "sprite %q not found", node.Speaker)
+		} else if node.Speaker == "" && node.Text != "" && len(node.Choices) == 0 {
+			p.errorf(path+".speaker", "speaker is required for a text without choices")
+		}
+		if node.Secs < 0 {
+			p.errorf(path+".secs", "%v is negative", node.Secs)
+		}
+		checkNext(path+".next", node.Next)
+		checkCond(path+".if", node.If)
+		for i, c := range node.Choices {
+			cpath := jsonPathIndex(path+".choices", i)
+			if c == nil {
+				p.errorf(cpath, "want object, got null")
+				continue
+			}
+			if c.Text == "" {
+				p.errorf(cpath+".text", "text is required")
+			}
+			checkNext(cpath+".next", c.Next)
+			checkCond(cpath+".if", c.If)
+		}
+	}
+}
+
+// zorderProps are properties of special shapes in zorder, a "?" suffix of
+// kind means the property is optional.
+var zorderProps = map[string]map[string]string{
+	"monitor": {
+		"target": "string", "val": "string", "name": "string", "label": "string",
+		"mode": "number", "x": "number", "y": "number", "visible": "boolean",
+		"size": "number?", "color": "color?",
+	},
+	"measure": {
+		"size": "number", "x": "number", "y": "number",
+		"scale": "number?", "heading": "number?", "color": "color?",
+	},
+	"sprite": {
+		"target": "string",
+	},
+	"sprites": {
+		"target": "string", "items": "array",
+	},
+}
+
+// spriteProps are properties applied by applySpriteProps.
+var spriteProps = map[string]string{
+	"x": "number?", "y": "number?", "size": "number?", "heading": "number?",
+	"visible": "boolean?", "rotationStyle": "string?", "costumeIndex": "number?",
+}
+
+func (p *checker) checkSpecialShape(path string, v specsp) {
+	typ, ok := v["type"].(string)
+	if !ok {
+		p.errorf(path+".type", "want string, got %s", jsonText(v["type"]))
+		return
+	}
+	if typ == "stageMonitor" {
+		typ = "monitor"
+	}
+	props, ok := zorderProps[typ]
+	if !ok {
+		p.errorf(path+".type", "unknown shape type %q", typ)
+		return
+	}
+	p.checkProps(path, v, props)
+	switch typ {
+	case "sprite":
+		p.checkProps(path, v, spriteProps)
+		if target, ok := v["target"].(string); ok && !p.sprites[target] {
+			p.errorf(path+".target", "sprite %q not found", target)
+		}
+	case "sprites":
+		items, _ := v["items"].([]any)
+		for i, item := range items {
+			if item, ok := item.(map[string]any); ok {
+				p.checkProps(jsonPathIndex(path+".items", i), item, spriteProps)
+			} else {
+				p.errorf(jsonPathIndex(path+".items", i), "want object, got %s", jsonText(item))
+			}
+		}
+	}
+}
+
+func (p *checker) checkProps(path string, v specsp, props map[string]string) {
+	for _, key := range sortedKeys(props) {
+		kind, optional := strings.CutSuffix(props[key], "?")
+		val, ok := v[key]
+		if !ok {
+			if !optional {
+				p.errorf(path, "missing property %q", key)
+			}
+			continue
+		}
+		switch kind {
+		case "string":
+			_, ok = val.(string)
+		case "number":
+			_, ok = val.(float64)
+		case "boolean":
+			_, ok = val.(bool)
+		case "array":
+			_, ok = val.([]any)
+		case "color":
+			if _, err := mathf.NewColorAny(val); err != nil {
+				p.errorf(jsonPathKey(path, key), "invalid color %s: %v", jsonText(val), err)
+			}
+			continue
+		}
+		if !ok {
+			p.errorf(jsonPathKey(path, key), "want %s, got %s", kind, jsonText(val))
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *checker) checkSprite(base string, conf *spriteConfig) {
+	names := p.checkCostumes(base, conf)
+	n := len(names)
+	if idx := conf.getCostumeIndex(); idx != 0 && (idx < 0 || idx >= n) {
+		p.errorf("$.costumeIndex", "index %d out of range [0, %d)", idx, n)
+	}
+	switch conf.RotationStyle {
+	case "", "normal", "left-right", "none":
+	default:
+		p.errorf("$.rotationStyle", "unknown rotation style %q", conf.RotationStyle)
+	}
+	if conf.Tint != "" {
+		if _, err := mathf.NewColorAny(conf.Tint); err != nil {
+			p.errorf("$.tint", "invalid color %q: %v", conf.Tint, err)
+		}
+	}
+	if conf.Opacity != nil && (*conf.Opacity < 0 || *conf.Opacity > 100) {
+		p.errorf("$.opacity", "%v out of range [0, 100]", *conf.Opacity)
+	}
+	p.checkColliderType("$.colliderType", conf.ColliderType)
+	p.checkColliderType("$.triggerType", conf.TriggerType)
+	p.checkShader("$.shader", conf.Shader)
+	if conf.Font != "" {
+		p.checkPath("$.font", conf.Font)
+	}
+
+	anims := make(map[string]bool)
+	if conf.CostumeAtlas != nil && conf.CostumeAtlas.frames != nil {
+		for name := range atlasAnimations(conf.CostumeAtlas) {
+			anims[name] = true
+		}
+	}
+	for _, name := range sortedKeys(conf.FAnimations) {
+		anims[name] = true
+		if ani := conf.FAnimations[name]; ani != nil {
+			p.checkAnimation(jsonPathKey("$.fAnimations", name), ani, names)
+		}
+	}
+	if conf.DefaultAnimation != "" && !anims[conf.DefaultAnimation] {
+		p.errorf("$.defaultAnimation", "animation %q not found", conf.DefaultAnimation)
+	}
+	for _, state := range sortedKeys(conf.AnimBindings) {
+		if name := conf.AnimBindings[state]; !anims[name] {
+			p.errorf(jsonPathKey("$.animBindings", state), "animation %q not found", name)
+		}
+	}
+	if conf.AnimStates != nil {
+		for i, state := range conf.AnimStates.States {
+			if state == nil {
+				continue
+			}
+			name := state.Anim
+			if name == "" {
+				name = state.Name
+			}
+			if bound, ok := conf.AnimBindings[name]; ok {
+				name = bound
+			}
+			if !anims[name] {
+				p.errorf(jsonPathIndex("$.animStates.states", i)+".anim", "animation %q not found", name)
+			}
+		}
+	}
+}
+
+func (p *checker) checkColliderType(path, typ string) {
+	switch typ {
+	case "", "none", "auto", "circle", "rect":
+	default:
+		p.errorf(path, "unknown collider type %q", typ)
+	}
+}
+
+// checkCostumes checks costume sources of a sprite, and returns names of all
+// costumes in order.
+func (p *checker) checkCostumes(base string, conf *spriteConfig) (names []string) {
+	switch {
+	case conf.Costumes != nil:
+		for i, c := range conf.Costumes {
+			if c == nil {
+				continue
+			}
+			p.checkPath(jsonPathIndex("$.costumes", i)+".path", path.Join(base, c.Path))
+			names = append(names, c.Name)
+		}
+	case conf.CostumeSet != nil:
+		cs := conf.CostumeSet
+		p.checkPath("$.costumeSet.path", path.Join(base, cs.Path))
+		names = p.checkCostumeSetPart("$.costumeSet", cs.Nx, cs.Items, names)
+	case conf.CostumeMPSet != nil:
+		cmps := conf.CostumeMPSet
+		p.checkPath("$.costumeMPSet.path", path.Join(base, cmps.Path))
+		for i, part := range cmps.Parts {
+			names = p.checkCostumeSetPart(jsonPathIndex("$.costumeMPSet.parts", i), part.Nx, part.Items, names)
+		}
+	case conf.CostumeAtlas != nil:
+		atlas := conf.CostumeAtlas
+		p.checkPath("$.costumeAtlas.path", path.Join(base, atlas.Path))
+		file := p.file
+		err := loadCostumeAtlas(p.fs, base, atlas)
+		p.file = file
+		if err != nil {
+			atlas.frames = nil
+			p.errorf("$.costumeAtlas", "%v", err)
+			return
+		}
+		p.checkPath("$.costumeAtlas.image", atlas.imagePath)
+		for _, frame := range atlas.frames {
+			names = append(names, atlasCostumeName(frame.Filename))
+		}
+	default:
+		p.errorf("$", "one of costumes, costumeSet, costumeMPSet and costumeAtlas is required")
+	}
+	return
+}
+
+// checkCostumeSetPart follows the naming rules of initCSPart.
+func (p *checker) checkCostumeSetPart(path string, nx int, items []costumeSetItem, names []string) []string {
+	if nx <= 0 {
+		p.errorf(path+".nx", "want positive integer, got %d", nx)
+		return names
+	}
+	if nx == 1 || items == nil {
+		for i := 0; i < nx; i++ {
+			names = append(names, strconv.Itoa(len(names)))
+		}
+		return names
+	}
+	total := 0
+	for _, item := range items {
+		for i := 0; i < item.N; i++ {
+			names = append(names, item.NamePrefix+strconv.Itoa(i))
+		}
+		total += item.N
+	}
+	if total != nx {
+		p.errorf(path+".items", "items have %d costumes in total, but nx is %d", total, nx)
+	}
+	return names
+}
+
+func (p *checker) checkAnimation(path string, ani *aniConfig, costumes []string) {
+	p.checkFrame(path+".frameFrom", ani.FrameFrom, costumes)
+	p.checkFrame(path+".frameTo", ani.FrameTo, costumes)
+	if ani.FrameFps < 0 {
+		p.errorf(path+".frameFps", "want non-negative integer, got %d", ani.FrameFps)
+	}
+	for _, key := range sortedKeys(ani.Events) {
+		if _, err := strconv.Atoi(key); err != nil {
+			p.errorf(jsonPathKey(path+".events", key), "event key should be a frame index")
+		}
+	}
+}
+
+// checkFrame checks a frame of animation, which is a costume name or index.
+func (p *checker) checkFrame(path string, frame any, costumes []string) {
+	switch v := frame.(type) {
+	case nil:
+	case string:
+		for _, name := range costumes {
+			if name == v {
+				return
+			}
+		}
+		p.errorf(path, "costume %q not found", v)
+	case float64:
+		if v != math.Trunc(v) || v < 0 || int(v) >= len(costumes) {
+			p.errorf(path, "frame %v out of range [0, %d)", v, len(costumes))
+		}
+	default:
+		p.errorf(path, "want costume name or index, got %s", jsonText(v))
+	}
+}
+
+// -------------------------------------------------------------------------------------
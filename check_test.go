@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type checkTestItem struct {
+	ID     int           `json:"id"`
+	Action *actionConfig `json:"action"`
+}
+
+type checkTestBase struct {
+	Base string `json:"base"`
+}
+
+type checkTestConf struct {
+	checkTestBase
+	Name   string                    `json:"name"`
+	Count  int                       `json:"count"`
+	Scale  float64                   `json:"scale"`
+	On     bool                      `json:"on"`
+	Tags   []string                  `json:"tags"`
+	Items  map[string]*checkTestItem `json:"items"`
+	Value  any                       `json:"value"`
+	NoTag  int
+	Hidden int `json:"-"`
+}
+
+func TestCheckValue(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string // path: msg
+	}{
+		{"valid", `{"base": "b", "name": "a", "count": 3, "scale": 1.5, "on": true,
+			"tags": ["x"], "items": {"a": {"id": 1}}, "value": [1, "x"], "NoTag": 1}`, nil},
+		{"null", `{"name": null, "items": {"a": null}}`, nil},
+		{"case insensitive", `{"NAME": "a", "notag": 1}`, nil},
+		{"unknown fields", `{"nmae": "a", "Hidden": 1, "items": {"a": {"iD": 1, "x y": 2}}}`, []string{
+			`$.Hidden: unknown field "Hidden"`,
+			`$.items.a["x y"]: unknown field "x y"`,
+			`$.nmae: unknown field "nmae"`,
+		}},
+		{"types", `{"name": 1, "count": 1.5, "scale": "2", "on": 0, "tags": "x", "items": []}`, []string{
+			`$.count: want integer, got 1.5`,
+			`$.items: want object, got []`,
+			`$.name: want string, got 1`,
+			`$.on: want boolean, got 0`,
+			`$.scale: want number, got "2"`,
+			`$.tags: want array, got "x"`,
+		}},
+		{"nested", `{"tags": ["a", 2], "items": {"a": {"id": "1"}, "b": 2}}`, []string{
+			`$.items.a.id: want integer, got "1"`,
+			`$.items.b: want object, got 2`,
+			`$.tags[1]: want string, got 2`,
+		}},
+		{"shorthand", `{"items": {"a": {"action": "msg"}, "b": {"action": 1}, "c": {"action": {"play": 1}}}}`, []string{
+			`$.items.b.action: invalid value 1`,
+			`$.items.c.action.play: want string, got 1`,
+		}},
+		{"not object", `[1]`, []string{`$: want object, got [1]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc any
+			if err := json.Unmarshal([]byte(tt.in), &doc); err != nil {
+				t.Fatal(err)
+			}
+			c := &checker{}
+			c.checkValue("$", doc, reflect.TypeOf(checkTestConf{}))
+			var got []string
+			for _, e := range c.errs {
+				got = append(got, e.Path+": "+e.Msg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
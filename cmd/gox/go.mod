@@ -9,6 +9,7 @@ require (
 	github.com/realdream-ai/mathf v0.0.0-20250513071532-e55e1277a8c5 // indirect
 	golang.org/x/image v0.23.0 // indirect
 	golang.org/x/mobile v0.0.0-20220518205345-8578da9835fd // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
 
 replace github.com/goplus/spx/v2 => ../../
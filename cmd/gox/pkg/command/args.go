@@ -26,6 +26,7 @@ type ExtraArgs struct {
 	Build           *string
 	Mode            *string
 	Movie           *bool
+	Watch           *bool
 }
 
 func (e *ExtraArgs) String() []string {
@@ -54,6 +55,9 @@ func (e *ExtraArgs) String() []string {
 	if *e.FullScreen {
 		args = append(args, "--fullscreen")
 	}
+	if *e.Watch {
+		args = append(args, "--watch")
+	}
 	return args
 }
 
@@ -61,7 +65,7 @@ func (e *ExtraArgs) String() []string {
 func (pself *CmdTool) CheckCmd(ext ...string) bool {
 	cmds := []string{
 		"help", "version", "editor",
-		"init", "clear", "clearbuild",
+		"init", "clear", "clearbuild", "check",
 		"build", "buildtinygo", "rune", "export",
 		"runweb", "buildweb", "exportweb", "stopweb", "runwebworker",
 		"runm", "exportbot", "exportapk", "exportios",
@@ -112,6 +116,7 @@ func (cmd *CmdTool) initializeFlags() *bool {
 	cmd.Args.Build = f.String("build", "normal", "build mode: normal or fast")
 	cmd.Args.Mode = f.String("mode", "none", "mode: none, worker, minigame")
 	cmd.Args.Movie = f.Bool("movie", false, "record movie mode")
+	cmd.Args.Watch = f.Bool("watch", false, "reload changed assets while running")
 	return help
 }
 
@@ -166,6 +171,7 @@ Available commands:
     - init            # Create a #CMDNAME project in the current directory
     - clear           # Clear the project
     - clearbuild      # Clear build artifacts
+    - check           # Check index.json files of the project
 
     Development & Building:
     - build           # Build the dynamic library
@@ -199,6 +205,8 @@ Examples:
     #CMDNAME init                         # Create a project in current path
     #CMDNAME init ./test/demo01           # Create a project at path ./test/demo01
     #CMDNAME run --path ./myproject       # Run project at specified path
+    #CMDNAME run --watch                  # Run and reload changed images and JSON files
+    #CMDNAME check --path ./myproject     # Check project files at specified path
     #CMDNAME build --servermode           # Build in server mode
     #CMDNAME runweb --debugweb            # Run web server with debug service
     #CMDNAME buildtinygo                  # Build TinyGo static library for ESP32
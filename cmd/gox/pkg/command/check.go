@@ -0,0 +1,27 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+
+	spx "github.com/goplus/spx/v2"
+)
+
+// Check validates index.json, scenes/*.json, dialogues/*.json and
+// sprites/*/index.json in the assets directory of the project, and prints all
+// problems found.
+func (pself *CmdTool) Check() error {
+	dir := filepath.Join(filepath.Dir(pself.ProjectDir), "assets")
+	errs, err := spx.CheckProject(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d problems found in %s", len(errs), dir)
+	}
+	fmt.Println("no problems found in", dir)
+	return nil
+}
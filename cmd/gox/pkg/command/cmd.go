@@ -145,6 +145,12 @@ func (cmd *CmdTool) handleSpecialCommands() bool {
 			fmt.Fprintf(os.Stderr, "Failed to stop web server: %v\n", err)
 		}
 		return true
+	case "check":
+		if err := cmd.Check(); err != nil {
+			fmt.Fprintf(os.Stderr, "Check failed: %v\n", err)
+			os.Exit(1)
+		}
+		return true
 	}
 	return false
 }
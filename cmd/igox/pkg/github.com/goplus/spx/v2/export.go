@@ -17,12 +17,16 @@ func init() {
 		Name: "spx",
 		Path: "github.com/goplus/spx/v2",
 		Deps: map[string]string{
-			"encoding/json":                     "json",
-			"flag":                              "flag",
-			"fmt":                               "fmt",
-			"github.com/goplus/spx/v2/fs":       "fs",
-			"github.com/goplus/spx/v2/fs/asset": "asset",
-			"github.com/goplus/spx/v2/fs/zip":   "zip",
+			"bytes":                              "bytes",
+			"cmp":                                "cmp",
+			"encoding/json":                      "json",
+			"errors":                             "errors",
+			"flag":                               "flag",
+			"fmt":                                "fmt",
+			"github.com/goplus/spx/v2/fs":        "fs",
+			"github.com/goplus/spx/v2/fs/asset":  "asset",
+			"github.com/goplus/spx/v2/fs/httpfs": "httpfs",
+			"github.com/goplus/spx/v2/fs/zip":    "zip",
 			"github.com/goplus/spx/v2/internal/audiorecord":     "audiorecord",
 			"github.com/goplus/spx/v2/internal/coroutine":       "coroutine",
 			"github.com/goplus/spx/v2/internal/debug":           "debug",
@@ -30,15 +34,23 @@ func init() {
 			"github.com/goplus/spx/v2/internal/engine/platform": "platform",
 			"github.com/goplus/spx/v2/internal/engine/profiler": "profiler",
 			"github.com/goplus/spx/v2/internal/enginewrap":      "enginewrap",
+			"github.com/goplus/spx/v2/internal/text":            "text",
 			"github.com/goplus/spx/v2/internal/time":            "time",
 			"github.com/goplus/spx/v2/internal/timer":           "timer",
 			"github.com/goplus/spx/v2/internal/tools":           "tools",
 			"github.com/goplus/spx/v2/internal/ui":              "ui",
 			"github.com/goplus/spx/v2/pkg/gdspx/pkg/engine":     "engine",
 			"github.com/realdream-ai/mathf":                     "mathf",
+			"golang.org/x/image/font":                           "font",
+			"golang.org/x/image/font/basicfont":                 "basicfont",
+			"golang.org/x/image/font/gofont/goregular":          "goregular",
+			"golang.org/x/image/math/fixed":                     "fixed",
+			"image":                                             "image",
+			"image/color":                                       "color",
 			"image/jpeg":                                        "jpeg",
 			"image/png":                                         "png",
 			"io":                                                "io",
+			"io/fs":                                             "fs",
 			"log":                                               "log",
 			"maps":                                              "maps",
 			"math":                                              "math",
@@ -47,6 +59,8 @@ func init() {
 			"path":                                              "path",
 			"path/filepath":                                     "filepath",
 			"reflect":                                           "reflect",
+			"slices":                                            "slices",
+			"sort":                                              "sort",
 			"strconv":                                           "strconv",
 			"strings":                                           "strings",
 			"sync":                                              "sync",
@@ -63,10 +77,15 @@ func init() {
 			"Widget":      reflect.TypeOf((*q.Widget)(nil)).Elem(),
 		},
 		NamedTypes: map[string]reflect.Type{
+			"AnimateOptions":  reflect.TypeOf((*q.AnimateOptions)(nil)).Elem(),
+			"AskRecord":       reflect.TypeOf((*q.AskRecord)(nil)).Elem(),
+			"AttachOptions":   reflect.TypeOf((*q.AttachOptions)(nil)).Elem(),
 			"Camera":          reflect.TypeOf((*q.Camera)(nil)).Elem(),
+			"CheckError":      reflect.TypeOf((*q.CheckError)(nil)).Elem(),
 			"Color":           reflect.TypeOf((*q.Color)(nil)).Elem(),
 			"Config":          reflect.TypeOf((*q.Config)(nil)).Elem(),
 			"EffectKind":      reflect.TypeOf((*q.EffectKind)(nil)).Elem(),
+			"EventHandle":     reflect.TypeOf((*q.EventHandle)(nil)).Elem(),
 			"Game":            reflect.TypeOf((*q.Game)(nil)).Elem(),
 			"List":            reflect.TypeOf((*q.List)(nil)).Elem(),
 			"Monitor":         reflect.TypeOf((*q.Monitor)(nil)).Elem(),
@@ -75,10 +94,12 @@ func init() {
 			"PlayAction":      reflect.TypeOf((*q.PlayAction)(nil)).Elem(),
 			"PlayOptions":     reflect.TypeOf((*q.PlayOptions)(nil)).Elem(),
 			"RotationStyle":   reflect.TypeOf((*q.RotationStyle)(nil)).Elem(),
+			"SceneTransition": reflect.TypeOf((*q.SceneTransition)(nil)).Elem(),
 			"Sound":           reflect.TypeOf((*q.Sound)(nil)).Elem(),
 			"SoundEffectKind": reflect.TypeOf((*q.SoundEffectKind)(nil)).Elem(),
 			"SpriteImpl":      reflect.TypeOf((*q.SpriteImpl)(nil)).Elem(),
 			"StopKind":        reflect.TypeOf((*q.StopKind)(nil)).Elem(),
+			"ThreadInfo":      reflect.TypeOf((*q.ThreadInfo)(nil)).Elem(),
 			"TurningInfo":     reflect.TypeOf((*q.TurningInfo)(nil)).Elem(),
 			"Value":           reflect.TypeOf((*q.Value)(nil)).Elem(),
 		},
@@ -95,6 +116,8 @@ func init() {
 		},
 		Vars: map[string]reflect.Value{},
 		Funcs: map[string]reflect.Value{
+			"CheckProject":             reflect.ValueOf(q.CheckProject),
+			"DecodeMsg":                reflect.ValueOf(q.DecodeMsg),
 			"Exit__0":                  reflect.ValueOf(q.Exit__0),
 			"Exit__1":                  reflect.ValueOf(q.Exit__1),
 			"Forever":                  reflect.ValueOf(q.Forever),
@@ -114,6 +137,7 @@ func init() {
 			"Sched":                    reflect.ValueOf(q.Sched),
 			"SchedNow":                 reflect.ValueOf(q.SchedNow),
 			"SetDebug":                 reflect.ValueOf(q.SetDebug),
+			"T":                        reflect.ValueOf(q.T),
 			"WaitUntil":                reflect.ValueOf(q.WaitUntil),
 		},
 		TypedConsts: map[string]ixgo.TypedConst{
@@ -273,13 +297,21 @@ func init() {
 			"StateTurn":            {reflect.TypeOf(q.StateTurn), constant.MakeString(string(q.StateTurn))},
 			"ThisScript":           {reflect.TypeOf(q.ThisScript), constant.MakeInt64(int64(q.ThisScript))},
 			"ThisSprite":           {reflect.TypeOf(q.ThisSprite), constant.MakeInt64(int64(q.ThisSprite))},
+			"TransitionFade":       {reflect.TypeOf(q.TransitionFade), constant.MakeInt64(int64(q.TransitionFade))},
+			"TransitionNone":       {reflect.TypeOf(q.TransitionNone), constant.MakeInt64(int64(q.TransitionNone))},
+			"TransitionSlideDown":  {reflect.TypeOf(q.TransitionSlideDown), constant.MakeInt64(int64(q.TransitionSlideDown))},
+			"TransitionSlideLeft":  {reflect.TypeOf(q.TransitionSlideLeft), constant.MakeInt64(int64(q.TransitionSlideLeft))},
+			"TransitionSlideRight": {reflect.TypeOf(q.TransitionSlideRight), constant.MakeInt64(int64(q.TransitionSlideRight))},
+			"TransitionSlideUp":    {reflect.TypeOf(q.TransitionSlideUp), constant.MakeInt64(int64(q.TransitionSlideUp))},
 			"Up":                   {reflect.TypeOf(q.Up), constant.MakeFromLiteral("0", token.FLOAT, 0)},
 			"WhirlEffect":          {reflect.TypeOf(q.WhirlEffect), constant.MakeInt64(int64(q.WhirlEffect))},
 		},
 		UntypedConsts: map[string]ixgo.UntypedConst{
-			"All":        {"untyped int", constant.MakeInt64(int64(q.All))},
-			"GopPackage": {"untyped bool", constant.MakeBool(bool(q.GopPackage))},
-			"Gop_sched":  {"untyped string", constant.MakeString(string(q.Gop_sched))},
+			"All":              {"untyped int", constant.MakeInt64(int64(q.All))},
+			"AnimParamMoving":  {"untyped string", constant.MakeString(string(q.AnimParamMoving))},
+			"AnimParamTurning": {"untyped string", constant.MakeString(string(q.AnimParamTurning))},
+			"GopPackage":       {"untyped bool", constant.MakeBool(bool(q.GopPackage))},
+			"Gop_sched":        {"untyped string", constant.MakeString(string(q.Gop_sched))},
 		},
 	})
 }
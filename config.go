@@ -18,7 +18,10 @@ package spx
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"log"
+	"os"
 	"syscall"
 
 	spxfs "github.com/goplus/spx/v2/fs"
@@ -35,20 +38,62 @@ func resourceDir(resource any) (fs spxfs.Dir, err error) {
 }
 
 func loadJson(ret any, fs spxfs.Dir, file string) (err error) {
-	if _, ok := fs.(spxfs.GdDir); ok {
-		filePath := engine.ToAssetPath(file)
-		value := engine.ReadAllText(filePath)
-		json.Unmarshal([]byte(value), ret)
+	data, err := readFile(fs, file)
+	if err != nil {
+		log.Println("Error: failed to open file", file, err)
 		return
 	}
+	if err = json.Unmarshal(data, ret); err != nil {
+		err = fmt.Errorf("%s: %w", file, err)
+	}
+	return
+}
+
+// loadIndexJson loads index.json of the project or a sprite. Errors are only
+// logged for GdDir projects, as they always were, so a project with a broken
+// file still runs; checkProject reports them as warnings.
+func loadIndexJson(ret any, fs spxfs.Dir, file string) error {
+	err := loadJson(ret, fs, file)
+	if _, ok := fs.(spxfs.GdDir); ok && err != nil {
+		log.Println("Warning:", err)
+		return nil
+	}
+	return err
+}
+
+func readFile(fs spxfs.Dir, file string) ([]byte, error) {
+	if _, ok := fs.(spxfs.GdDir); ok {
+		value := engine.ReadAllText(engine.ToAssetPath(file))
+		if value == "" {
+			return nil, os.ErrNotExist
+		}
+		return []byte(value), nil
+	}
+	return readBinaryFile(fs, file)
+}
 
+// readBinaryFile reads a binary file such as a font. Unlike readFile, it always
+// reads by fs.Open, as the engine reads GdDir files as UTF-8 texts.
+func readBinaryFile(fs spxfs.Dir, file string) ([]byte, error) {
 	f, err := fs.Open(file)
 	if err != nil {
-		println("Error: failed to open file", file, err)
-		return
+		return nil, err
 	}
 	defer f.Close()
-	return json.NewDecoder(f).Decode(ret)
+	return io.ReadAll(f)
+}
+
+// hasFile reports whether file exists in fs.
+func hasFile(fs spxfs.Dir, file string) bool {
+	if _, ok := fs.(spxfs.GdDir); ok {
+		return engine.HasFile(engine.ToAssetPath(file))
+	}
+	f, err := fs.Open(file)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
 }
 
 func loadProjConfig(proj *projConfig, fs spxfs.Dir, index any) (err error) {
@@ -56,9 +101,9 @@ func loadProjConfig(proj *projConfig, fs spxfs.Dir, index any) (err error) {
 	case io.Reader:
 		err = json.NewDecoder(v).Decode(proj)
 	case string:
-		err = loadJson(&proj, fs, v)
+		err = loadIndexJson(&proj, fs, v)
 	case nil:
-		err = loadJson(&proj, fs, "index.json")
+		err = loadIndexJson(&proj, fs, "index.json")
 	default:
 		return syscall.EINVAL
 	}
@@ -77,6 +122,10 @@ type Config struct {
 	DontParseFlags     bool   `json:"-"`
 	FullScreen         bool   `json:"fullScreen,omitempty"`
 	DontRunOnUnfocused bool   `json:"pauseOnUnfocused,omitempty"`
+	EventQueueSize     int    `json:"eventQueueSize,omitempty"` // max pending input events, 0 means the default size (256)
+	Watch              bool   `json:"-"`                        // reload changed assets (images and JSON files)
+	Preload            bool   `json:"preload,omitempty"`        // load all assets before the game starts, see Game.Preload
+	Language           string `json:"language,omitempty"`       // language of texts, the OS/browser language is used if empty
 }
 
 type cameraConfig struct {
@@ -117,6 +166,9 @@ type projConfig struct {
 	Run           *Config           `json:"run"`
 	Debug         bool              `json:"debug"`
 	Bgm           string            `json:"bgm"`
+	Shader        *shaderConfig     `json:"shader"` // custom shader of the backdrop
+	Font          string            `json:"font"`   // font file of texts, see Game.SetFont
+	Tilemaps      []*tilemapConfig  `json:"tilemaps"`
 
 	// deprecated properties
 	Scenes              []*backdropConfig `json:"scenes"`              //this property is deprecated, use Backdrops instead
@@ -186,6 +238,19 @@ type costumeMPSet struct {
 	Parts            []costumeSetPart `json:"parts"`
 }
 
+type costumeAtlas struct {
+	Path             string  `json:"path"`      // TexturePacker or Aseprite JSON data file
+	Image            string  `json:"image"`     // image path relative to the data file, default is meta.image
+	FaceRight        float64 `json:"faceRight"` // turn face to right
+	BitmapResolution int     `json:"bitmapResolution"`
+
+	// runtime
+	imagePath string
+	imageSize mathf.Vec2
+	frames    []atlasFrame
+	frameTags []atlasFrameTag
+}
+
 type costumeConfig struct {
 	Name             string  `json:"name"`
 	Path             string  `json:"path"`
@@ -216,8 +281,20 @@ type costumesConfig struct {
 }
 
 type actionConfig struct {
-	Play     string          `json:"play"`     //play sound
-	Costumes *costumesConfig `json:"costumes"` //play frame
+	Play      string          `json:"play"`      //play sound
+	Costumes  *costumesConfig `json:"costumes"`  //play frame
+	Broadcast string          `json:"broadcast"` //broadcast message
+}
+
+// UnmarshalJSON accepts a string as a shorthand of {"broadcast": "msg"}.
+func (p *actionConfig) UnmarshalJSON(data []byte) error {
+	var msg string
+	if err := json.Unmarshal(data, &msg); err == nil {
+		*p = actionConfig{Broadcast: msg}
+		return nil
+	}
+	type action actionConfig
+	return json.Unmarshal(data, (*action)(p))
 }
 
 type aniConfig struct {
@@ -227,21 +304,32 @@ type aniConfig struct {
 	StepDuration   float64 `json:"stepDuration"`
 	TurnToDuration float64 `json:"turnToDuration"`
 
-	AniType      aniTypeEnum   `json:"anitype"`
-	OnStart      *actionConfig `json:"onStart"` //start
-	OnPlay       *actionConfig `json:"onPlay"`  //play
-	IsLoop       bool          `json:"isLoop"`
-	IsKeepOnStop bool          `json:"isKeepOnStop"` //After finishing playback, it stays on the last frame and does not need to switch to the default animation
+	AniType      aniTypeEnum              `json:"anitype"`
+	OnStart      *actionConfig            `json:"onStart"` //start
+	OnPlay       *actionConfig            `json:"onPlay"`  //play
+	OnEnd        *actionConfig            `json:"onEnd"`   //stop
+	Events       map[string]*actionConfig `json:"events"`  //frame index => action, eg. {"3": "footstep"}
+	IsLoop       bool                     `json:"isLoop"`
+	IsKeepOnStop bool                     `json:"isKeepOnStop"` //After finishing playback, it stays on the last frame and does not need to switch to the default animation
 	Duration     float64
 
 	// runtime
 	IFrameFrom int
 	IFrameTo   int
+	frames     []int // costumes of frames if they aren't from IFrameFrom to IFrameTo, eg. pingpong
+
+	Speed     float64
+	IsReverse bool
+	From      any
+	To        any
+}
 
-	Speed float64
-	From  any
-	To    any
-	//OnEnd *actionConfig  `json:"onEnd"`   //stop
+// frameSeq returns costume indexes of frames of the animation.
+func (p *aniConfig) frameSeq() []int {
+	if p.frames != nil {
+		return p.frames
+	}
+	return framesBetween(p.IFrameFrom, p.IFrameTo)
 }
 
 // -------------------------------------------------------------------------------------
@@ -255,16 +343,25 @@ type spriteConfig struct {
 	Costumes            []*costumeConfig      `json:"costumes"`
 	CostumeSet          *costumeSet           `json:"costumeSet"`
 	CostumeMPSet        *costumeMPSet         `json:"costumeMPSet"`
+	CostumeAtlas        *costumeAtlas         `json:"costumeAtlas"`
 	CurrentCostumeIndex *int                  `json:"currentCostumeIndex"`
 	CostumeIndex        int                   `json:"costumeIndex"`
 	FAnimations         map[string]*aniConfig `json:"fAnimations"`
 	MAnimations         map[string]*aniConfig `json:"mAnimations"`
 	TAnimations         map[string]*aniConfig `json:"tAnimations"`
 	Visible             bool                  `json:"visible"`
+	Persistent          bool                  `json:"persistent"` // kept when another scene is loaded
 	IsDraggable         bool                  `json:"isDraggable"`
 	Pivot               mathf.Vec2            `json:"pivot"`
+	Tint                string                `json:"tint"` // color name or "#rrggbb[aa]"
+	FlipH               bool                  `json:"flipH"`
+	FlipV               bool                  `json:"flipV"`
+	Opacity             *float64              `json:"opacity"` // in range [0, 100], default is 100
 	DefaultAnimation    string                `json:"defaultAnimation"`
 	AnimBindings        map[string]string     `json:"animBindings"`
+	AnimStates          *animStatesConfig     `json:"animStates"`
+	Shader              *shaderConfig         `json:"shader"`
+	Font                string                `json:"font"` // font file of speech bubbles, see SetFont
 	CollisionMask       *int64                `json:"collisionMask"`
 	CollisionLayer      *int64                `json:"collisionLayer"`
 	TriggerMask         *int64                `json:"triggerMask"`
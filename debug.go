@@ -18,7 +18,9 @@ package spx
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/goplus/spx/v2/internal/coroutine"
 	"github.com/goplus/spx/v2/internal/engine"
 	"github.com/goplus/spx/v2/internal/engine/profiler"
 	"github.com/goplus/spx/v2/internal/time"
@@ -60,5 +62,97 @@ func (p *Game) showDebugPanel() {
 	msg += fmt.Sprintf("coro: NextCount: %v\n", lastInfo.NextCount)
 	msg += fmt.Sprintf("coro: GCCount: %v\n", lastInfo.GCCount)
 	msg += fmt.Sprintf("coro: LoopIterations: %v\n", lastInfo.LoopIterations)
+	evStats := p.events.getStats()
+	msg += fmt.Sprintf("event: Pending: %v\n", evStats.Pending)
+	msg += fmt.Sprintf("event: MaxLen: %v\n", evStats.MaxLen)
+	msg += fmt.Sprintf("event: Dropped: %v\n", evStats.Dropped)
+	msg += fmt.Sprintf("event: Coalesced: %v\n", evStats.Coalesced)
+	threads := p.Threads()
+	msg += fmt.Sprintf("threads: %v\n", len(threads))
+	for i, th := range threads {
+		if i >= maxDebugPanelThreads {
+			msg += "  ...\n"
+			break
+		}
+		msg += "  " + th.String() + "\n"
+	}
 	p.debugPanel.Show(msg)
 }
+
+// -----------------------------------------------------------------------------
+
+const maxDebugPanelThreads = 16
+
+// ThreadInfo describes a live script (coroutine).
+type ThreadInfo struct {
+	Id        int64
+	Name      string
+	Owner     string  // owner sprite name, "Game" for stage scripts, "" for engine threads
+	WaitType  string  // "frame", "time", "main", "yield", "task" or "" if running
+	WakeTime  float64 // wake-up time (in seconds since level load) when WaitType is "time"
+	WaitSince int64   // frame when the wait started
+	Paused    bool
+	Stopped   bool
+	Stack     string // creation stack, only recorded when debug is enabled in the project
+}
+
+func (p *ThreadInfo) String() string {
+	owner := p.Owner
+	if owner == "" {
+		owner = "-"
+	}
+	state := p.WaitType
+	switch {
+	case p.Stopped:
+		state = "stopped"
+	case p.Paused:
+		state = "paused"
+	case state == "":
+		state = "running"
+	case state == "time":
+		state = fmt.Sprintf("time(%.2f)", p.WakeTime)
+	}
+	return fmt.Sprintf("#%d %s %s since:%d", p.Id, owner, state, p.WaitSince)
+}
+
+// Threads returns all live scripts, ordered by creation.
+func (p *Game) Threads() []ThreadInfo {
+	ths := gco.Threads()
+	ret := make([]ThreadInfo, len(ths))
+	for i, th := range ths {
+		ret[i] = ThreadInfo{
+			Id:        th.Id,
+			Name:      th.Name,
+			Owner:     threadOwner(th),
+			WaitType:  th.WaitType,
+			WakeTime:  th.WakeTime,
+			WaitSince: th.WaitSince,
+			Paused:    th.Paused,
+			Stopped:   th.Stopped,
+			Stack:     th.Stack,
+		}
+	}
+	return ret
+}
+
+// DumpThreads returns a readable report of all live scripts, including their
+// creation stacks.
+func (p *Game) DumpThreads() string {
+	var b strings.Builder
+	for _, th := range p.Threads() {
+		b.WriteString(th.String())
+		b.WriteByte('\n')
+		if th.Stack != "" {
+			b.WriteString(th.Stack)
+		}
+	}
+	return b.String()
+}
+
+func threadOwner(th coroutine.ThreadInfo) string {
+	switch th.Obj.(type) {
+	case *SpriteImpl, *Game:
+		return nameOf(th.Obj)
+	}
+	return ""
+}
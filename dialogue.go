@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/goplus/spx/v2/internal/tools"
+)
+
+// -------------------------------------------------------------------------------------
+// dialogues: a dialogue is a conversation graph in dialogues/<name>.json:
+//
+//	{
+//	  "start": "hello",
+//	  "nodes": {
+//	    "hello": {"speaker": "Cat", "text": "Hello!", "secs": 1.5, "next": "ask", "broadcast": "wave"},
+//	    "ask": {"speaker": "Cat", "text": "Want some fish?", "choices": [
+//	      {"text": "Yes", "next": "yes"},
+//	      {"text": "No", "next": "no", "if": "Cat.hungry == false"}
+//	    ]},
+//	    "yes": {"speaker": "Dog", "text": "Me too!", "if": "score >= 10"},
+//	    "no": {"speaker": "Cat", "text": "OK", "think": true}
+//	  }
+//	}
+//
+// A node is said by its speaker (a sprite) in a speech bubble for secs seconds,
+// or asked with its choices. A node without speaker is shown by the ask panel
+// of the stage, without an input box unless it has choices. Choices picked
+// aren't added to AskHistory. A node (or a choice) is skipped if its "if"
+// condition is false, which compares a variable of the game (eg. "score") or a
+// sprite (eg. "Cat.hungry") with a number, a quoted string or a boolean, or
+// tests a single variable. A node broadcasts its message (if any) when it's
+// entered. The conversation ends at a node without next.
+
+const dialogueDefaultSecs = 2.0
+
+type dialogueChoice struct {
+	Text string `json:"text"`
+	Next string `json:"next"`
+	If   string `json:"if"`
+}
+
+type dialogueNode struct {
+	Speaker   string            `json:"speaker"` // sprite name, empty for the stage
+	Text      string            `json:"text"`
+	Think     bool              `json:"think"`
+	Secs      float64           `json:"secs"` // how long the text is shown, dialogueDefaultSecs if 0
+	Choices   []*dialogueChoice `json:"choices"`
+	Next      string            `json:"next"`
+	If        string            `json:"if"`
+	Broadcast string            `json:"broadcast"`
+}
+
+type dialogueConfig struct {
+	Start string                   `json:"start"`
+	Nodes map[string]*dialogueNode `json:"nodes"`
+}
+
+func dialogueFile(name string) string {
+	return "dialogues/" + name + ".json"
+}
+
+// RunDialogue runs the conversation dialogues/<name>.json, and returns when it
+// ends.
+func (p *Game) RunDialogue(name string) {
+	if debugInstr {
+		log.Println("RunDialogue", name)
+	}
+	var d dialogueConfig
+	if err := loadJson(&d, p.fs, dialogueFile(name)); err != nil {
+		log.Println("RunDialogue:", err)
+		return
+	}
+	// nodes visited without waiting, a node visited again waits for a frame so
+	// that a cycle of skipped nodes doesn't freeze the game
+	visited := make(map[string]bool)
+	for id := d.Start; id != ""; {
+		node := d.Nodes[id]
+		if node == nil {
+			log.Println("RunDialogue: node not found -", name, id)
+			return
+		}
+		if visited[id] {
+			engine.WaitNextFrame()
+			clear(visited)
+		}
+		visited[id] = true
+		if !p.dialogueCond(node.If) {
+			id = node.Next
+			continue
+		}
+		var waited bool
+		if id, waited = p.runDialogueNode(node); waited {
+			clear(visited)
+		}
+	}
+}
+
+// runDialogueNode shows a node and returns the next node, waited reports
+// whether the node is shown.
+func (p *Game) runDialogueNode(node *dialogueNode) (next string, waited bool) {
+	if node.Broadcast != "" {
+		p.Broadcast__0(node.Broadcast)
+	}
+	var spr *SpriteImpl
+	if node.Speaker != "" {
+		if spr = spriteOf(p.sprs[node.Speaker]); spr == nil {
+			log.Println("RunDialogue: sprite not found -", node.Speaker)
+			return node.Next, false
+		}
+	}
+	if len(node.Choices) == 0 {
+		if node.Text == "" {
+			return node.Next, false
+		}
+		secs := node.Secs
+		if secs <= 0 {
+			secs = dialogueDefaultSecs
+		}
+		if spr == nil {
+			p.showStageText(tr(node.Text), secs)
+			return node.Next, true
+		}
+		style := styleSay
+		if node.Think {
+			style = styleThink
+		}
+		spr.sayOrThink(node.Text, style)
+		engine.Wait(secs)
+		spr.doStopSay()
+		return node.Next, true
+	}
+
+	var options []string
+	var nexts []string
+	for _, c := range node.Choices {
+		if c != nil && p.dialogueCond(c.If) {
+			options = append(options, c.Text)
+			nexts = append(nexts, c.Next)
+		}
+	}
+	if len(options) == 0 {
+		return node.Next, false
+	}
+	if rec := p.pickChoice(spr, node.Text, 0, options); rec.Choice >= 0 {
+		return nexts[rec.Choice], true
+	}
+	return node.Next, true
+}
+
+// -------------------------------------------------------------------------------------
+
+type dialogueCond struct {
+	target string // sprite name, empty for the game
+	name   string
+	op     string // empty to test the variable itself
+	value  any    // float64, string or bool
+}
+
+var condOps = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// parseDialogueCond parses a condition like "score >= 10", "Cat.name == 'Tom'"
+// or "Cat.hungry".
+func parseDialogueCond(s string) (*dialogueCond, error) {
+	c := &dialogueCond{}
+	lhs, pos := s, -1
+	for _, op := range condOps { // the first operator, ">=" is matched before ">"
+		if i := strings.Index(s, op); i >= 0 && (pos < 0 || i < pos) {
+			pos, c.op = i, op
+		}
+	}
+	if pos >= 0 {
+		lhs = s[:pos]
+		rhs := strings.TrimSpace(s[pos+len(c.op):])
+		switch {
+		case rhs == "true" || rhs == "false":
+			c.value = rhs == "true"
+		case len(rhs) >= 2 && (rhs[0] == '"' || rhs[0] == '\'') && rhs[len(rhs)-1] == rhs[0]:
+			c.value = rhs[1 : len(rhs)-1]
+		default:
+			v, err := strconv.ParseFloat(rhs, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rhs)
+			}
+			c.value = v
+		}
+	}
+	lhs = strings.TrimSpace(lhs)
+	if target, name, ok := strings.Cut(lhs, "."); ok {
+		c.target, c.name = target, name
+	} else {
+		c.name = lhs
+	}
+	if c.name == "" {
+		return nil, errors.New("variable name is empty")
+	}
+	return c, nil
+}
+
+// dialogueCond evaluates a condition, an empty condition is true.
+func (p *Game) dialogueCond(s string) bool {
+	if s == "" {
+		return true
+	}
+	c, err := parseDialogueCond(s)
+	if err != nil {
+		log.Println("RunDialogue: invalid condition", s, "-", err)
+		return false
+	}
+	g := reflect.ValueOf(p.gamer_).Elem()
+	target, from := getTarget(g, c.target)
+	if from < 0 {
+		log.Println("RunDialogue: sprite not found -", c.target)
+		return false
+	}
+	ref := getValueRef(target, c.name, from)
+	if !ref.IsValid() {
+		log.Println("RunDialogue: variable not found -", s)
+		return false
+	}
+	return c.eval(ref.Interface())
+}
+
+func (c *dialogueCond) eval(v any) bool {
+	if c.op == "" {
+		if f, ok := tools.GetFloat(v); ok {
+			return f != 0
+		}
+		return !reflect.ValueOf(v).IsZero()
+	}
+	var r int
+	switch want := c.value.(type) {
+	case float64:
+		f, ok := tools.GetFloat(v)
+		if !ok {
+			return false
+		}
+		r = cmp.Compare(f, want)
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		r = strings.Compare(s, want)
+	case bool:
+		b, ok := v.(bool)
+		if !ok || (c.op != "==" && c.op != "!=") {
+			return false
+		}
+		if b != want {
+			r = 1
+		}
+	}
+	switch c.op {
+	case "==":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">=":
+		return r >= 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	default: // "<"
+		return r < 0
+	}
+}
+
+// -------------------------------------------------------------------------------------
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDialogueCond(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *dialogueCond
+		wantErr bool
+	}{
+		{"hungry", &dialogueCond{name: "hungry"}, false},
+		{" Cat.hungry ", &dialogueCond{target: "Cat", name: "hungry"}, false},
+		{"score >= 10", &dialogueCond{name: "score", op: ">=", value: 10.0}, false},
+		{"score>10", &dialogueCond{name: "score", op: ">", value: 10.0}, false},
+		{"score<=-1.5", &dialogueCond{name: "score", op: "<=", value: -1.5}, false},
+		{"score < 3", &dialogueCond{name: "score", op: "<", value: 3.0}, false},
+		{"Cat.name == 'Tom'", &dialogueCond{target: "Cat", name: "name", op: "==", value: "Tom"}, false},
+		{`name != "a >= b"`, &dialogueCond{name: "name", op: "!=", value: "a >= b"}, false},
+		{"done == true", &dialogueCond{name: "done", op: "==", value: true}, false},
+		{"done != false", &dialogueCond{name: "done", op: "!=", value: false}, false},
+		{"", nil, true},
+		{"== 1", nil, true},
+		{"score > ten", nil, true},
+		{"name == 'Tom", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDialogueCond(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDialogueCond(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDialogueCond(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialogueCondEval(t *testing.T) {
+	tests := []struct {
+		cond string
+		v    any
+		want bool
+	}{
+		{"n", 1, true},
+		{"n", 0.0, false},
+		{"s", "", false},
+		{"s", "x", true},
+		{"b", true, true},
+		{"b", false, false},
+		{"n == 3", 3, true},
+		{"n == 3", int64(3), true},
+		{"n != 3", 3.5, true},
+		{"n >= 3", 3, true},
+		{"n > 3", 3, false},
+		{"n <= 2", 3, false},
+		{"n < 4", 3.9, true},
+		{"n == 3", "3", true},
+		{"n == 3", "three", false},
+		{"s == 'Tom'", "Tom", true},
+		{"s != 'Tom'", "Tom", false},
+		{"s < 'b'", "a", true},
+		{"s == 'Tom'", 1, false},
+		{"b == true", true, true},
+		{"b != true", false, true},
+		{"b == false", 0, false},
+		{"b > false", true, false},
+	}
+	for _, tt := range tests {
+		c, err := parseDialogueCond(tt.cond)
+		if err != nil {
+			t.Fatalf("parseDialogueCond(%q): %v", tt.cond, err)
+		}
+		if got := c.eval(tt.v); got != tt.want {
+			t.Errorf("%q with %#v = %v, want %v", tt.cond, tt.v, got, tt.want)
+		}
+	}
+}
@@ -17,7 +17,10 @@
 package spx
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"reflect"
 	"sync"
 
 	"github.com/goplus/spx/v2/internal/coroutine"
@@ -30,9 +33,40 @@ import (
 
 type eventSink struct {
 	prev  *eventSink
+	head  **eventSink // the list this sink belongs to
 	pthis threadObj
 	cond  func(any) bool
 	sink  any
+	once  bool // remove this sink after it fires
+}
+
+func (p *eventSink) doRemove(ev *eventSink) (ret *eventSink) {
+	ret = p
+	pp := &ret
+	for {
+		p := *pp
+		if p == nil {
+			return
+		}
+		if p == ev {
+			*pp = p.prev
+			return
+		}
+		pp = &p.prev
+	}
+}
+
+func (p *eventSink) remove() {
+	if p.head != nil {
+		*p.head = (*p.head).doRemove(p)
+		p.head = nil
+	}
+}
+
+func (p *eventSink) fired() {
+	if p.once {
+		p.remove()
+	}
 }
 
 func (p *eventSink) doDeleteClone(this any) (ret *eventSink) {
@@ -45,6 +79,7 @@ func (p *eventSink) doDeleteClone(this any) (ret *eventSink) {
 		}
 		if p.pthis == this {
 			*pp = p.prev
+			p.head = nil
 		} else {
 			pp = &p.prev
 		}
@@ -54,6 +89,7 @@ func (p *eventSink) doDeleteClone(this any) (ret *eventSink) {
 func (p *eventSink) asyncCall(start bool, data any, doSth func(*eventSink)) {
 	for p != nil {
 		if p.cond == nil || p.cond(data) {
+			p.fired()
 			copy := p
 			gco.CreateAndStart(start, p.pthis, func(coroutine.Thread) int {
 				doSth(copy)
@@ -68,6 +104,7 @@ func (p *eventSink) syncCall(data any, doSth func(*eventSink)) {
 	var wg sync.WaitGroup
 	for p != nil {
 		if p.cond == nil || p.cond(data) {
+			p.fired()
 			wg.Add(1)
 			copy := p
 			gco.CreateAndStart(false, p.pthis, func(coroutine.Thread) int {
@@ -91,6 +128,40 @@ func (p *eventSink) call(wait bool, data any, doSth func(*eventSink)) {
 
 // -------------------------------------------------------------------------------------
 
+// EventHandle represents an event handler registered by an On* method. It is
+// used to unregister the handler, eg. when a sprite switches from menu mode to
+// gameplay mode.
+type EventHandle struct {
+	ev *eventSink
+}
+
+// Remove unregisters the handler. Scripts already started by the handler keep
+// running. It is safe to call Remove more than once.
+func (p *EventHandle) Remove() {
+	if p != nil && p.ev != nil {
+		p.ev.remove()
+		p.ev = nil
+	}
+}
+
+// Stop is an alias of Remove.
+func (p *EventHandle) Stop() {
+	p.Remove()
+}
+
+// Removed reports whether the handler has been unregistered, either by Remove
+// or because it was registered by a Once* method and has fired.
+func (p *EventHandle) Removed() bool {
+	return p == nil || p.ev == nil || p.ev.head == nil
+}
+
+func (p *EventHandle) setOnce() *EventHandle {
+	p.ev.once = true
+	return p
+}
+
+// -------------------------------------------------------------------------------------
+
 type eventSinkMgr struct {
 	allWhenStart           *eventSink
 	allWhenKeyPressed      *eventSink
@@ -104,6 +175,11 @@ type eventSinkMgr struct {
 	allWhenMoving          *eventSink
 	allWhenTurning         *eventSink
 	allWhenTimer           *eventSink
+	allWhenAnimFinished    *eventSink
+	allWhenAnimLooped      *eventSink
+	allWhenScreenshot      *eventSink
+	allWhenSceneLoaded     *eventSink
+	allWhenLoadProgress    *eventSink
 	calledStart            bool
 }
 
@@ -120,6 +196,11 @@ func (p *eventSinkMgr) reset() {
 	p.allWhenMoving = nil
 	p.allWhenTurning = nil
 	p.allWhenTimer = nil
+	p.allWhenAnimFinished = nil
+	p.allWhenAnimLooped = nil
+	p.allWhenScreenshot = nil
+	p.allWhenSceneLoaded = nil
+	p.allWhenLoadProgress = nil
 	p.calledStart = false
 }
 
@@ -136,6 +217,11 @@ func (p *eventSinkMgr) doDeleteClone(this any) {
 	p.allWhenMoving = p.allWhenMoving.doDeleteClone(this)
 	p.allWhenTurning = p.allWhenTurning.doDeleteClone(this)
 	p.allWhenTimer = p.allWhenTimer.doDeleteClone(this)
+	p.allWhenAnimFinished = p.allWhenAnimFinished.doDeleteClone(this)
+	p.allWhenAnimLooped = p.allWhenAnimLooped.doDeleteClone(this)
+	p.allWhenScreenshot = p.allWhenScreenshot.doDeleteClone(this)
+	p.allWhenSceneLoaded = p.allWhenSceneLoaded.doDeleteClone(this)
+	p.allWhenLoadProgress = p.allWhenLoadProgress.doDeleteClone(this)
 }
 
 func (p *eventSinkMgr) doWhenStart() {
@@ -150,12 +236,57 @@ func (p *eventSinkMgr) doWhenStart() {
 	}
 }
 
+// doWhenStartOf calls onStart handlers of objs, which are started after the
+// game has been started (eg. sprites of a scene loaded by LoadScene).
+func (p *eventSinkMgr) doWhenStartOf(objs map[threadObj]bool) {
+	for ev := p.allWhenStart; ev != nil; ev = ev.prev {
+		if objs[ev.pthis] {
+			ev.fired()
+			copy := ev
+			gco.CreateAndStart(false, ev.pthis, func(coroutine.Thread) int {
+				if debugEvent {
+					log.Println("==> onStart", nameOf(copy.pthis))
+				}
+				copy.sink.(func())()
+				return 0
+			})
+		}
+	}
+}
+
 func (p *eventSinkMgr) doWhenTimer(time float64) {
 	p.allWhenTimer.asyncCall(false, time, func(ev *eventSink) {
 		ev.sink.(func(float64))(time)
 	})
 }
 
+func (p *eventSinkMgr) doWhenScreenshot(path string) {
+	p.allWhenScreenshot.asyncCall(false, path, func(ev *eventSink) {
+		if debugEvent {
+			log.Println("==> onScreenshot", path)
+		}
+		ev.sink.(func(string))(path)
+	})
+}
+
+func (p *eventSinkMgr) doWhenSceneLoaded(name string) {
+	p.allWhenSceneLoaded.asyncCall(false, name, func(ev *eventSink) {
+		if debugEvent {
+			log.Println("==> onSceneLoaded", name)
+		}
+		ev.sink.(func(string))(name)
+	})
+}
+
+func (p *eventSinkMgr) doWhenLoadProgress(percent float64) {
+	p.allWhenLoadProgress.asyncCall(false, percent, func(ev *eventSink) {
+		if debugEvent {
+			log.Println("==> onLoadProgress", percent)
+		}
+		ev.sink.(func(float64))(percent)
+	})
+}
+
 func (p *eventSinkMgr) doWhenKeyPressed(key Key) {
 	p.allWhenKeyPressed.asyncCall(false, key, func(ev *eventSink) {
 		ev.sink.(func(Key))(key)
@@ -219,10 +350,52 @@ func (p *eventSinkMgr) doWhenTurning(this threadObj, mi *TurningInfo) {
 	})
 }
 
+func (p *eventSinkMgr) doWhenAnimFinished(this threadObj, name SpriteAnimationName) {
+	p.allWhenAnimFinished.asyncCall(false, this, func(ev *eventSink) {
+		if debugEvent {
+			log.Println("==> onAnimationFinished", nameOf(this), name)
+		}
+		ev.sink.(func(SpriteAnimationName))(name)
+	})
+}
+
+func (p *eventSinkMgr) doWhenAnimLooped(this threadObj, name SpriteAnimationName) {
+	p.allWhenAnimLooped.asyncCall(false, this, func(ev *eventSink) {
+		if debugEvent {
+			log.Println("==> onAnimationLooped", nameOf(this), name)
+		}
+		ev.sink.(func(SpriteAnimationName))(name)
+	})
+}
+
 func (p *eventSinkMgr) doWhenIReceive(msg string, data any, wait bool) {
 	p.allWhenIReceive.call(wait, msg, func(ev *eventSink) {
-		ev.sink.(func(string, any))(msg, data)
+		callMsgSink(ev, msg, data)
+	})
+}
+
+// doWhenIReceiveAndWait calls all matched handlers, waits for them to finish and
+// returns the replies of handlers registered by OnMsg__3.
+func (p *eventSinkMgr) doWhenIReceiveAndWait(msg string, data any) (replies []any) {
+	var mutex sync.Mutex
+	p.allWhenIReceive.syncCall(msg, func(ev *eventSink) {
+		if reply, ok := callMsgSink(ev, msg, data); ok {
+			mutex.Lock()
+			replies = append(replies, reply)
+			mutex.Unlock()
+		}
 	})
+	return
+}
+
+func callMsgSink(ev *eventSink, msg string, data any) (reply any, ok bool) {
+	switch sink := ev.sink.(type) {
+	case func(string, any):
+		sink(msg, data)
+	case func(string, any) any:
+		return sink(msg, data), true
+	}
+	return
 }
 
 func (p *eventSinkMgr) doWhenBackdropChanged(name BackdropName, wait bool) {
@@ -233,17 +406,28 @@ func (p *eventSinkMgr) doWhenBackdropChanged(name BackdropName, wait bool) {
 
 // -------------------------------------------------------------------------------------
 type IEventSinks interface {
-	OnAnyKey(onKey func(key Key))
-	OnBackdrop__0(onBackdrop func(name BackdropName))
-	OnBackdrop__1(name BackdropName, onBackdrop func())
-	OnClick(onClick func())
-	OnKey__0(key Key, onKey func())
-	OnKey__1(keys []Key, onKey func(Key))
-	OnKey__2(keys []Key, onKey func())
-	OnMsg__0(onMsg func(msg string, data any))
-	OnMsg__1(msg string, onMsg func())
-	OnStart(onStart func())
-	OnTimer(time float64, onTimer func())
+	OnAnyKey(onKey func(key Key)) *EventHandle
+	OnBackdrop__0(onBackdrop func(name BackdropName)) *EventHandle
+	OnBackdrop__1(name BackdropName, onBackdrop func()) *EventHandle
+	OnClick(onClick func()) *EventHandle
+	OnKey__0(key Key, onKey func()) *EventHandle
+	OnKey__1(keys []Key, onKey func(Key)) *EventHandle
+	OnKey__2(keys []Key, onKey func()) *EventHandle
+	OnLoadProgress(onProgress func(percent float64)) *EventHandle
+	OnMsg__0(onMsg func(msg string, data any)) *EventHandle
+	OnMsg__1(msg string, onMsg func()) *EventHandle
+	OnMsg__2(msg string, onMsg func(data any)) *EventHandle
+	OnMsg__3(msg string, onMsg func(data any) any) *EventHandle
+	OnSceneLoaded(onLoaded func(name string)) *EventHandle
+	OnScreenshot(onScreenshot func(path string)) *EventHandle
+	OnStart(onStart func()) *EventHandle
+	OnTimer(time float64, onTimer func()) *EventHandle
+	OnceKey__0(key Key, onKey func()) *EventHandle
+	OnceKey__1(keys []Key, onKey func(Key)) *EventHandle
+	OnceMsg__0(msg string, onMsg func()) *EventHandle
+	OnceMsg__1(msg string, onMsg func(data any)) *EventHandle
+	Pause(kind StopKind)
+	Resume(kind StopKind)
 	Stop(kind StopKind)
 }
 
@@ -278,38 +462,66 @@ func (p *eventSinks) doDeleteClone() {
 
 // -------------------------------------------------------------------------------------
 
-func (p *eventSinks) OnStart(onStart func()) {
-	p.allWhenStart = &eventSink{
-		prev:  p.allWhenStart,
+func (p *eventSinks) addSink(head **eventSink, ev *eventSink) *EventHandle {
+	ev.prev, ev.head = *head, head
+	*head = ev
+	return &EventHandle{ev: ev}
+}
+
+func (p *eventSinks) OnStart(onStart func()) *EventHandle {
+	return p.addSink(&p.allWhenStart, &eventSink{
 		pthis: p.pthis,
 		sink:  onStart,
-	}
+	})
 }
 
-func (p *eventSinks) OnClick(onClick func()) {
+func (p *eventSinks) OnClick(onClick func()) *EventHandle {
 	pthis := p.pthis
-	p.allWhenClick = &eventSink{
-		prev:  p.allWhenClick,
+	return p.addSink(&p.allWhenClick, &eventSink{
 		pthis: pthis,
 		sink:  onClick,
 		cond: func(data any) bool {
 			return data == pthis
 		},
-	}
+	})
 }
 
-func (p *eventSinks) OnAnyKey(onKey func(key Key)) {
-	p.allWhenKeyPressed = &eventSink{
-		prev:  p.allWhenKeyPressed,
+func (p *eventSinks) OnAnyKey(onKey func(key Key)) *EventHandle {
+	return p.addSink(&p.allWhenKeyPressed, &eventSink{
 		pthis: p.pthis,
 		sink:  onKey,
-	}
+	})
+}
+
+// OnLoadProgress is called with percent in range [0, 100] while Preload is
+// loading assets.
+func (p *eventSinks) OnLoadProgress(onProgress func(percent float64)) *EventHandle {
+	return p.addSink(&p.allWhenLoadProgress, &eventSink{
+		pthis: p.pthis,
+		sink:  onProgress,
+	})
 }
 
-func (p *eventSinks) OnTimer(time float64, call func()) {
+// OnSceneLoaded is called after a scene is loaded by LoadScene, before the
+// transition in starts.
+func (p *eventSinks) OnSceneLoaded(onLoaded func(name string)) *EventHandle {
+	return p.addSink(&p.allWhenSceneLoaded, &eventSink{
+		pthis: p.pthis,
+		sink:  onLoaded,
+	})
+}
+
+// OnScreenshot is called after Screenshot or CapturePen saves a file.
+func (p *eventSinks) OnScreenshot(onScreenshot func(path string)) *EventHandle {
+	return p.addSink(&p.allWhenScreenshot, &eventSink{
+		pthis: p.pthis,
+		sink:  onScreenshot,
+	})
+}
+
+func (p *eventSinks) OnTimer(time float64, call func()) *EventHandle {
 	timer.RegisterTimer(time)
-	p.allWhenTimer = &eventSink{
-		prev:  p.allWhenTimer,
+	return p.addSink(&p.allWhenTimer, &eventSink{
 		pthis: p.pthis,
 		sink: func(float64) {
 			if debugEvent {
@@ -320,12 +532,11 @@ func (p *eventSinks) OnTimer(time float64, call func()) {
 		cond: func(data any) bool {
 			return mathf.Absf(data.(float64)-time) < 0.001
 		},
-	}
+	})
 }
 
-func (p *eventSinks) OnKey__0(key Key, onKey func()) {
-	p.allWhenKeyPressed = &eventSink{
-		prev:  p.allWhenKeyPressed,
+func (p *eventSinks) OnKey__0(key Key, onKey func()) *EventHandle {
+	return p.addSink(&p.allWhenKeyPressed, &eventSink{
 		pthis: p.pthis,
 		sink: func(Key) {
 			if debugEvent {
@@ -336,12 +547,11 @@ func (p *eventSinks) OnKey__0(key Key, onKey func()) {
 		cond: func(data any) bool {
 			return data.(Key) == key
 		},
-	}
+	})
 }
 
-func (p *eventSinks) OnKey__1(keys []Key, onKey func(Key)) {
-	p.allWhenKeyPressed = &eventSink{
-		prev:  p.allWhenKeyPressed,
+func (p *eventSinks) OnKey__1(keys []Key, onKey func(Key)) *EventHandle {
+	return p.addSink(&p.allWhenKeyPressed, &eventSink{
 		pthis: p.pthis,
 		sink: func(key Key) {
 			if debugEvent {
@@ -358,26 +568,24 @@ func (p *eventSinks) OnKey__1(keys []Key, onKey func(Key)) {
 			}
 			return false
 		},
-	}
+	})
 }
 
-func (p *eventSinks) OnKey__2(keys []Key, onKey func()) {
-	p.OnKey__1(keys, func(Key) {
+func (p *eventSinks) OnKey__2(keys []Key, onKey func()) *EventHandle {
+	return p.OnKey__1(keys, func(Key) {
 		onKey()
 	})
 }
 
-func (p *eventSinks) OnMsg__0(onMsg func(msg string, data any)) {
-	p.allWhenIReceive = &eventSink{
-		prev:  p.allWhenIReceive,
+func (p *eventSinks) OnMsg__0(onMsg func(msg string, data any)) *EventHandle {
+	return p.addSink(&p.allWhenIReceive, &eventSink{
 		pthis: p.pthis,
 		sink:  onMsg,
-	}
+	})
 }
 
-func (p *eventSinks) OnMsg__1(msg string, onMsg func()) {
-	p.allWhenIReceive = &eventSink{
-		prev:  p.allWhenIReceive,
+func (p *eventSinks) OnMsg__1(msg string, onMsg func()) *EventHandle {
+	return p.addSink(&p.allWhenIReceive, &eventSink{
 		pthis: p.pthis,
 		sink: func(msg string, data any) {
 			if debugEvent {
@@ -388,20 +596,115 @@ func (p *eventSinks) OnMsg__1(msg string, onMsg func()) {
 		cond: func(data any) bool {
 			return data.(string) == msg
 		},
+	})
+}
+
+func (p *eventSinks) OnMsg__2(msg string, onMsg func(data any)) *EventHandle {
+	return p.addSink(&p.allWhenIReceive, &eventSink{
+		pthis: p.pthis,
+		sink: func(msg string, data any) {
+			if debugEvent {
+				log.Println("==> onMsg", msg, nameOf(p.pthis))
+			}
+			onMsg(data)
+		},
+		cond: func(data any) bool {
+			return data.(string) == msg
+		},
+	})
+}
+
+// OnMsg__3 registers a handler whose return value is sent back as a reply to
+// BroadcastAndWait. Replies are ignored by Broadcast.
+func (p *eventSinks) OnMsg__3(msg string, onMsg func(data any) any) *EventHandle {
+	return p.addSink(&p.allWhenIReceive, &eventSink{
+		pthis: p.pthis,
+		sink: func(msg string, data any) any {
+			if debugEvent {
+				log.Println("==> onMsg", msg, nameOf(p.pthis))
+			}
+			return onMsg(data)
+		},
+		cond: func(data any) bool {
+			return data.(string) == msg
+		},
+	})
+}
+
+// OnceKey__0 is like OnKey__0, but the handler is removed after its first call.
+func (p *eventSinks) OnceKey__0(key Key, onKey func()) *EventHandle {
+	return p.OnKey__0(key, onKey).setOnce()
+}
+
+func (p *eventSinks) OnceKey__1(keys []Key, onKey func(Key)) *EventHandle {
+	return p.OnKey__1(keys, onKey).setOnce()
+}
+
+// OnceMsg__0 is like OnMsg__1, but the handler is removed after its first call.
+func (p *eventSinks) OnceMsg__0(msg string, onMsg func()) *EventHandle {
+	return p.OnMsg__1(msg, onMsg).setOnce()
+}
+
+func (p *eventSinks) OnceMsg__1(msg string, onMsg func(data any)) *EventHandle {
+	return p.OnMsg__2(msg, onMsg).setOnce()
+}
+
+// MsgData converts the payload of a message to type T. Numbers are converted
+// between numeric types, and other values (eg. map[string]any) are decoded
+// through their JSON form. It returns an error if data can't be represented as T.
+// It's generic, so use DecodeMsg in XGo scripts run by igox.
+func MsgData[T any](data any) (ret T, err error) {
+	err = decodeMsg("MsgData", data, reflect.ValueOf(&ret).Elem())
+	return
+}
+
+// DecodeMsg converts the payload of a message like MsgData, and stores it in
+// the value ptr points to.
+func DecodeMsg(data any, ptr any) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("DecodeMsg: want a non-nil pointer, got %T", ptr)
+	}
+	return decodeMsg("DecodeMsg", data, v.Elem())
+}
+
+func decodeMsg(fn string, data any, ret reflect.Value) error {
+	typ := ret.Type()
+	if data == nil {
+		return fmt.Errorf("%s: can't convert nil to %v", fn, typ)
 	}
+	val := reflect.ValueOf(data)
+	if val.Type().AssignableTo(typ) {
+		ret.Set(val)
+		return nil
+	}
+	if isNumberKind(val.Kind()) && isNumberKind(typ.Kind()) {
+		ret.Set(val.Convert(typ))
+		return nil
+	}
+	b, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(b, ret.Addr().Interface())
+	}
+	if err != nil {
+		return fmt.Errorf("%s: can't convert %T to %v: %w", fn, data, typ, err)
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
 }
 
-func (p *eventSinks) OnBackdrop__0(onBackdrop func(name BackdropName)) {
-	p.allWhenBackdropChanged = &eventSink{
-		prev:  p.allWhenBackdropChanged,
+func (p *eventSinks) OnBackdrop__0(onBackdrop func(name BackdropName)) *EventHandle {
+	return p.addSink(&p.allWhenBackdropChanged, &eventSink{
 		pthis: p.pthis,
 		sink:  onBackdrop,
-	}
+	})
 }
 
-func (p *eventSinks) OnBackdrop__1(name BackdropName, onBackdrop func()) {
-	p.allWhenBackdropChanged = &eventSink{
-		prev:  p.allWhenBackdropChanged,
+func (p *eventSinks) OnBackdrop__1(name BackdropName, onBackdrop func()) *EventHandle {
+	return p.addSink(&p.allWhenBackdropChanged, &eventSink{
 		pthis: p.pthis,
 		sink: func(name BackdropName) {
 			if debugEvent {
@@ -412,7 +715,7 @@ func (p *eventSinks) OnBackdrop__1(name BackdropName, onBackdrop func()) {
 		cond: func(data any) bool {
 			return data.(BackdropName) == name
 		},
-	}
+	})
 }
 
 // -------------------------------------------------------------------------------------
@@ -460,6 +763,63 @@ func (p *eventSinks) Stop(kind StopKind) {
 	gco.StopIf(filter)
 }
 
+// stopKindFilter returns the threads targeted by a StopKind. The current
+// thread is matched only by the kinds that include it.
+func (p *eventSinks) stopKindFilter(kind StopKind) func(th coroutine.Thread) bool {
+	this := p.pthis
+	me := gco.Current()
+	switch kind {
+	case All:
+		return func(th coroutine.Thread) bool {
+			return isSprite(th.Obj) || isGame(th.Obj)
+		}
+	case AllSprites:
+		return func(th coroutine.Thread) bool {
+			return isSprite(th.Obj)
+		}
+	case ThisSprite:
+		return func(th coroutine.Thread) bool {
+			return th.Obj == this
+		}
+	case OtherScriptsInSprite:
+		return func(th coroutine.Thread) bool {
+			return th.Obj == this && th != me
+		}
+	case AllOtherScripts:
+		return func(th coroutine.Thread) bool {
+			return (isSprite(th.Obj) || isGame(th.Obj)) && th != me
+		}
+	case ThisScript:
+		return func(th coroutine.Thread) bool {
+			return th == me
+		}
+	}
+	return func(th coroutine.Thread) bool {
+		return false
+	}
+}
+
+// Pause pauses the scripts specified by kind. Unlike Stop, paused scripts can
+// be continued by Resume with the same kind. If the current script is paused,
+// Pause returns after it is resumed.
+func (p *eventSinks) Pause(kind StopKind) {
+	if debugInstr {
+		log.Println("Pause", nameOf(p.pthis), kind)
+	}
+	gco.PauseIf(p.stopKindFilter(kind))
+	if me := gco.Current(); me != nil && me.Paused() {
+		engine.WaitNextFrame()
+	}
+}
+
+// Resume resumes the scripts paused by Pause.
+func (p *eventSinks) Resume(kind StopKind) {
+	if debugInstr {
+		log.Println("Resume", nameOf(p.pthis), kind)
+	}
+	gco.ResumeIf(p.stopKindFilter(kind))
+}
+
 func isGame(obj threadObj) bool {
 	_, ok := obj.(*Game)
 	return ok
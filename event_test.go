@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeMsg(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	cases := []struct {
+		data any
+		ptr  any // pointer to a zero value of the wanted type
+		want any
+		ok   bool
+	}{
+		{42, new(int), 42, true},
+		{42.0, new(int), 42, true},
+		{3, new(float64), 3.0, true},
+		{"hi", new(string), "hi", true},
+		{true, new(bool), true, true},
+		{[]any{1.0, 2.0}, new([]int), []int{1, 2}, true},
+		{map[string]any{"X": 1.0, "Y": 2.0}, new(point), point{1, 2}, true},
+		{point{3, 4}, new(point), point{3, 4}, true},
+		{"hi", new(any), "hi", true},
+		{"hi", new(int), 0, false},
+		{nil, new(string), "", false},
+	}
+	for _, c := range cases {
+		err := DecodeMsg(c.data, c.ptr)
+		if (err == nil) != c.ok {
+			t.Errorf("DecodeMsg(%#v): err = %v, want ok = %v", c.data, err, c.ok)
+			continue
+		}
+		if got := reflect.ValueOf(c.ptr).Elem().Interface(); c.ok && !reflect.DeepEqual(got, c.want) {
+			t.Errorf("DecodeMsg(%#v) = %#v, want %#v", c.data, got, c.want)
+		}
+	}
+	if err := DecodeMsg(1, 0); err == nil {
+		t.Error("DecodeMsg to a non-pointer: want an error")
+	}
+	if n, err := MsgData[int64](7.0); err != nil || n != 7 {
+		t.Errorf("MsgData[int64](7.0) = %v, %v", n, err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"log"
+	"sync"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/goplus/spx/v2/internal/text"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// -------------------------------------------------------------------------------------
+// fonts: TrueType or OpenType files in the assets directory. The project font
+// ("font" of index.json, or Game.SetFont) is the default font of the engine,
+// and it's also used to measure texts so that speech bubbles are wrapped by the
+// real width of glyphs. A sprite font ("font" of the sprite's index.json, or
+// SetFont) is used by speech bubbles of the sprite instead.
+
+// loadFont loads a font file, fonts are cached. It returns nil if the file
+// can't be loaded.
+func (p *Game) loadFont(file string) *text.Font {
+	if f, ok := p.fonts[file]; ok {
+		return f
+	}
+	data, err := readBinaryFile(p.fs, file)
+	var f *text.Font
+	if err == nil {
+		f, err = text.ParseFont(data)
+	}
+	if err != nil {
+		log.Println("loadFont:", file, err)
+	}
+	if p.fonts == nil {
+		p.fonts = make(map[string]*text.Font)
+	}
+	p.fonts[file] = f
+	return f
+}
+
+// SetFont sets the project font, file is relative to the assets directory.
+func (p *Game) SetFont(file string) {
+	if debugInstr {
+		log.Println("SetFont", file)
+	}
+	p.setFont(file)
+}
+
+func (p *Game) setFont(file string) {
+	if file == "" {
+		return
+	}
+	if p.font = p.loadFont(file); p.font != nil {
+		resMgr.SetDefaultFont(engine.ToAssetPath(file))
+	}
+}
+
+// textMeasurer returns the measurer of texts drawn by the engine.
+func (p *Game) textMeasurer() text.Measurer {
+	if p.font != nil {
+		return p.font
+	}
+	return text.Heuristic
+}
+
+// SetFont sets the font of the sprite, file is relative to the assets
+// directory. See also Game.SetFont.
+func (p *SpriteImpl) SetFont(file string) {
+	if debugInstr {
+		log.Println("SetFont", p.name, file)
+	}
+	p.setFont(file)
+}
+
+func (p *SpriteImpl) setFont(file string) {
+	p.font, p.fontFile = nil, ""
+	if file != "" {
+		if p.font = p.g.loadFont(file); p.font != nil {
+			p.fontFile = file
+		}
+	}
+}
+
+// textMeasurer returns the measurer of texts of the sprite, which is the
+// sprite font or the measurer of the project font.
+func (p *SpriteImpl) textMeasurer() text.Measurer {
+	if p.font != nil {
+		return p.font
+	}
+	return p.g.textMeasurer()
+}
+
+var (
+	fallbackFont     *text.Font
+	fallbackFontOnce sync.Once
+)
+
+// rasterFont returns the font of texts of the sprite drawn by the software
+// rasterizer and DrawText, which is the sprite font, the project font, or Go
+// Regular (set a font for texts other than Latin, Greek and Cyrillic).
+func (p *SpriteImpl) rasterFont() *text.Font {
+	if p.font != nil {
+		return p.font
+	}
+	if p.g.font != nil {
+		return p.g.font
+	}
+	fallbackFontOnce.Do(func() {
+		fallbackFont, _ = text.ParseFont(goregular.TTF)
+	})
+	return fallbackFont
+}
+
+// -------------------------------------------------------------------------------------
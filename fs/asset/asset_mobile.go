@@ -21,6 +21,7 @@ package asset
 
 import (
 	"io"
+	iofs "io/fs"
 
 	"github.com/goplus/spx/v2/fs"
 	"golang.org/x/mobile/asset"
@@ -37,3 +38,7 @@ func Open(base string) (fs.Dir, error) {
 func openAsset(path string) (io.ReadSeekCloser, error) {
 	return asset.Open(path)
 }
+
+func readAssetDir(path string) ([]iofs.DirEntry, error) {
+	return nil, fs.ErrNoReadDir // mobile assets can't be listed
+}
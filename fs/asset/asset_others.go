@@ -21,6 +21,7 @@ package asset
 
 import (
 	"io"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
 
@@ -35,3 +36,7 @@ func Open(base string) (fs.Dir, error) {
 func openAsset(path string) (io.ReadSeekCloser, error) {
 	return os.Open(filepath.FromSlash(path))
 }
+
+func readAssetDir(path string) ([]iofs.DirEntry, error) {
+	return os.ReadDir(filepath.FromSlash(path))
+}
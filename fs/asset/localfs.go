@@ -18,6 +18,7 @@ package asset
 
 import (
 	"io"
+	iofs "io/fs"
 
 	"github.com/goplus/spx/v2/fs"
 )
@@ -43,6 +44,11 @@ func (f *FS) GetPath() string {
 	return f.base
 }
 
+// ReadDir lists files of a directory.
+func (f *FS) ReadDir(dir string) ([]iofs.DirEntry, error) {
+	return readAssetDir(f.base + fs.CleanDir(dir))
+}
+
 func init() {
 	fs.RegisterSchema("", Open)
 }
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package embedfs
+
+import (
+	"io"
+	iofs "io/fs"
+	"path"
+
+	"github.com/goplus/spx/v2/fs"
+)
+
+// -------------------------------------------------------------------------------------
+
+// A FS represents a directory of an io/fs.FS, eg. an embed.FS, so that a single
+// binary can carry its assets:
+//
+//	//go:embed assets
+//	var assets embed.FS
+//
+//	dir, _ := embedfs.New(assets, "assets")
+//
+// The engine loads images, sounds and fonts from a local directory, spx copies
+// them into a cache directory when they are used first. It's not supported on
+// web and in pack mode, where the engine can't load them.
+type FS struct {
+	fsys iofs.FS
+}
+
+// New opens dir of fsys.
+func New(fsys iofs.FS, dir string) (fs.Dir, error) {
+	sub, err := iofs.Sub(fsys, fs.CleanDir(dir))
+	if err != nil {
+		return nil, err
+	}
+	return &FS{sub}, nil
+}
+
+// Open opens a file object.
+func (p *FS) Open(name string) (io.ReadCloser, error) {
+	return p.fsys.Open(path.Clean("/" + name)[1:])
+}
+
+// Close closes the filesystem object.
+func (p *FS) Close() error {
+	return nil
+}
+
+// ReadDir lists files of a directory.
+func (p *FS) ReadDir(dir string) ([]iofs.DirEntry, error) {
+	return iofs.ReadDir(p.fsys, fs.CleanDir(dir))
+}
+
+// -------------------------------------------------------------------------------------
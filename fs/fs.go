@@ -19,6 +19,7 @@ package fs
 import (
 	"errors"
 	"io"
+	iofs "io/fs"
 	"strings"
 )
 
@@ -33,6 +34,33 @@ type GdDir interface {
 	GetPath() string
 }
 
+// ReadDirFS is a Dir which can list files of a directory.
+type ReadDirFS interface {
+	Dir
+	ReadDir(dir string) ([]iofs.DirEntry, error)
+}
+
+// ErrNoReadDir is returned by ReadDir if a Dir can't list files.
+var ErrNoReadDir = errors.New("fs.ReadDir: listing files is not supported")
+
+// ReadDir lists files of a directory (use "" or "." for the root directory) in
+// fsys, sorted by filename.
+func ReadDir(fsys Dir, dir string) ([]iofs.DirEntry, error) {
+	if p, ok := fsys.(ReadDirFS); ok {
+		return p.ReadDir(dir)
+	}
+	return nil, ErrNoReadDir
+}
+
+// CleanDir converts dir to a valid path of io/fs, eg. "" to ".".
+func CleanDir(dir string) string {
+	dir = strings.Trim(dir, "/")
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
 func SplitSchema(path string) (schema, file string) {
 	idx := strings.IndexAny(path, ":/\\ ")
 	if idx < 0 || path[idx] != ':' {
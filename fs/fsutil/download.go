@@ -0,0 +1,117 @@
+//go:build !js
+// +build !js
+
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fsutil
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+)
+
+var (
+	// BaseDir is where downloaded files are cached.
+	BaseDir = os.Getenv("HOME") + "/.spx/"
+)
+
+// CachePath returns the local path of a downloaded file, url is without the
+// schema, eg. "open.qiniu.us/weather/res.zip".
+func CachePath(url string) string {
+	return BaseDir + path.Clean("/" + url)[1:]
+}
+
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Download downloads remote to local. The download is skipped if local is up to
+// date according to the ETag or Last-Modified header of the last download, and
+// local is used as it is if the server can't be reached or responds an error.
+func Download(remote, local string) (err error) {
+	metaFile := local + ".meta"
+	_, errStat := os.Stat(local)
+	hasLocal := errStat == nil
+
+	req, err := http.NewRequest("GET", remote, nil)
+	if err != nil {
+		return
+	}
+	if hasLocal {
+		var meta cacheMeta
+		if data, err := os.ReadFile(metaFile); err == nil && json.Unmarshal(data, &meta) == nil {
+			if meta.ETag != "" {
+				req.Header.Set("If-None-Match", meta.ETag)
+			}
+			if meta.LastModified != "" {
+				req.Header.Set("If-Modified-Since", meta.LastModified)
+			}
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if hasLocal { // offline
+			return nil
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasLocal {
+		return nil
+	}
+	if err = HttpError(remote, resp); err != nil {
+		if hasLocal {
+			log.Println("Download: use the cached file -", err)
+			return nil
+		}
+		return
+	}
+	if err = saveTo(local, resp.Body); err != nil {
+		return
+	}
+	meta := cacheMeta{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	if data, err := json.Marshal(&meta); err == nil {
+		os.WriteFile(metaFile, data, 0666)
+	}
+	return nil
+}
+
+// saveTo writes r to a temporary file first, so that local is never partly
+// written.
+func saveTo(local string, r io.Reader) (err error) {
+	os.MkdirAll(path.Dir(local), 0777)
+	tmp := local + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(f, r)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return
+	}
+	return os.Rename(tmp, local)
+}
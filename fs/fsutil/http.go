@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fsutil
+
+import (
+	"net/http"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// HttpError returns the error of a response, nil means the status is 200, and
+// ENOENT is returned if the status is 404.
+func HttpError(url string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.Wrapf(syscall.ENOENT, "`%s` not found", url)
+	}
+	return errors.Errorf("GET %s: %s", url, resp.Status)
+}
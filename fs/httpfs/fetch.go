@@ -0,0 +1,39 @@
+//go:build !js
+// +build !js
+
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpfs
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/goplus/spx/v2/fs/fsutil"
+)
+
+// fetch downloads a file to the cache directory, it's downloaded again only if
+// it's changed on the server.
+func fetch(url string) (io.ReadCloser, error) {
+	_, file, _ := strings.Cut(url, "://")
+	local := fsutil.CachePath(file)
+	if err := fsutil.Download(url, local); err != nil {
+		return nil, err
+	}
+	return os.Open(local)
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpfs
+
+import (
+	"io"
+	"strings"
+
+	"github.com/goplus/spx/v2/fs"
+)
+
+// -------------------------------------------------------------------------------------
+
+// A FS represents a directory on a HTTP server, files are fetched on demand.
+// Like embedfs, images, sounds and fonts are fetched into a cache directory
+// for the engine when they are used first.
+type FS struct {
+	base string // url of the directory, ends with "/"
+}
+
+// Open opens http:<domain>/<path>
+// Open("open.qiniu.us/weather/res")
+func Open(url string) (fs.Dir, error) {
+	return openWith(url, "http://")
+}
+
+// OpenHttps opens https:<domain>/<path>
+// OpenHttps("open.qiniu.us/weather/res")
+func OpenHttps(url string) (fs.Dir, error) {
+	return openWith(url, "https://")
+}
+
+func openWith(url string, schema string) (fs.Dir, error) {
+	return &FS{base: schema + strings.TrimSuffix(url, "/") + "/"}, nil
+}
+
+// Open fetches a file.
+func (p *FS) Open(name string) (io.ReadCloser, error) {
+	return fetch(p.base + strings.TrimPrefix(name, "/"))
+}
+
+// Close closes the filesystem object.
+func (p *FS) Close() error {
+	return nil
+}
+
+func init() {
+	fs.RegisterSchema("http", Open)
+	fs.RegisterSchema("https", OpenHttps)
+}
+
+// -------------------------------------------------------------------------------------
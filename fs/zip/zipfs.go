@@ -22,12 +22,11 @@ package zip
 import (
 	"archive/zip"
 	"io"
-	"net/http"
-	"os"
-	"path"
+	iofs "io/fs"
 	"syscall"
 
 	"github.com/goplus/spx/v2/fs"
+	"github.com/goplus/spx/v2/fs/fsutil"
 	"github.com/pkg/errors"
 )
 
@@ -60,6 +59,11 @@ func (zipf *FS) Close() error {
 	return ((*zip.ReadCloser)(zipf)).Close()
 }
 
+// ReadDir lists files of a directory.
+func (zipf *FS) ReadDir(dir string) ([]iofs.DirEntry, error) {
+	return iofs.ReadDir(&zipf.Reader, fs.CleanDir(dir))
+}
+
 // OpenHttp opens hzip:<domain>/<path>
 // OpenHttp("open.qiniu.us/weather/res.zip")
 func OpenHttp(url string) (fs.Dir, error) {
@@ -73,44 +77,13 @@ func OpenHttps(url string) (fs.Dir, error) {
 }
 
 func openHttpWith(url string, schema string) (dir fs.Dir, err error) {
-	local := spxBaseDir + url
-	dir, err = Open(local)
-	if err == nil {
-		return
-	}
-
-	remote := schema + url
-	resp, err := http.Get(remote)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
-
-	err = saveTo(local, resp)
-	if err != nil {
+	local := fsutil.CachePath(url)
+	if err = fsutil.Download(schema+url, local); err != nil {
 		return
 	}
 	return Open(local)
 }
 
-func saveTo(local string, resp *http.Response) (err error) {
-	dir := path.Dir(local)
-	os.MkdirAll(dir, 0777)
-
-	f, err := os.Create(local)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-
-	_, err = io.Copy(f, resp.Body)
-	return
-}
-
-var (
-	spxBaseDir = os.Getenv("HOME") + "/.spx/"
-)
-
 func init() {
 	fs.RegisterSchema("zip", Open)
 	fs.RegisterSchema("hzip", OpenHttp)
@@ -20,11 +20,13 @@ import (
 	"archive/zip"
 	"bytes"
 	"io"
+	iofs "io/fs"
 	"io/ioutil"
 	"net/http"
 	"syscall"
 
 	"github.com/goplus/spx/v2/fs"
+	"github.com/goplus/spx/v2/fs/fsutil"
 	"github.com/pkg/errors"
 )
 
@@ -55,6 +57,11 @@ func (zipf *FS) Close() error {
 	return nil
 }
 
+// ReadDir lists files of a directory.
+func (zipf *FS) ReadDir(dir string) ([]iofs.DirEntry, error) {
+	return iofs.ReadDir(zipf.Reader, fs.CleanDir(dir))
+}
+
 // OpenHttp opens hzip:<domain>/<path>
 // OpenHttp("open.qiniu.us/weather/res.zip")
 func OpenHttp(url string) (fs.Dir, error) {
@@ -74,6 +81,9 @@ func openHttpWith(url string, schema string) (dir fs.Dir, err error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err = fsutil.HttpError(remote, resp); err != nil {
+		return nil, err
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
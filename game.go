@@ -19,12 +19,14 @@ package spx
 import (
 	"flag"
 	"fmt"
+	"image"
 	"log"
 	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 	"unsafe"
@@ -34,6 +36,7 @@ import (
 	"github.com/goplus/spx/v2/internal/debug"
 	"github.com/goplus/spx/v2/internal/engine"
 	"github.com/goplus/spx/v2/internal/engine/platform"
+	"github.com/goplus/spx/v2/internal/text"
 	gtime "github.com/goplus/spx/v2/internal/time"
 	"github.com/goplus/spx/v2/internal/timer"
 	"github.com/goplus/spx/v2/internal/ui"
@@ -41,6 +44,7 @@ import (
 
 	spxfs "github.com/goplus/spx/v2/fs"
 	_ "github.com/goplus/spx/v2/fs/asset"
+	_ "github.com/goplus/spx/v2/fs/httpfs"
 	_ "github.com/goplus/spx/v2/fs/zip"
 )
 
@@ -102,8 +106,15 @@ type Game struct {
 	items        []Shape                 // shapes on stage (in Zorder), not only sprites
 	destroyItems []Shape                 // shapes on stage (in Zorder), not only sprites
 	tempItems    []Shape                 // temp items
+	penShapes    []*penShape             // everything drawn by pens, but not in penBaked yet
+	tilemaps     []*tilemap              // tilemap layers, from bottom to top
 
-	events    chan event
+	penPoints int                         // number of points in penShapes
+	penBaked  map[*SpriteImpl]*image.RGBA // older pen shapes rasterized, by sprite
+
+	assetCache *assetCache // nil if the engine loads assets from fs directly
+
+	events    *eventQueue
 	aurec     *audiorecord.Recorder
 	startFlag sync.Once
 
@@ -121,13 +132,28 @@ type Game struct {
 	sinkMgr  eventSinkMgr
 	isLoaded bool
 	isRunned bool
+	isPaused bool // paused by PauseGame
 	gamer_   Gamer
 
+	watchIndex any // index.json to reload in watch mode
+
+	sceneName      string // current scene loaded by LoadScene, empty for index.json
+	isLoadingScene bool
+
+	manifest       *assetManifest
+	preloaded      map[string]*engine.Sprite // asset path => hidden sprite holding the texture
+	preloadOnStart bool
+
+	font  *text.Font            // project font, see SetFont
+	fonts map[string]*text.Font // loaded fonts, nil if a file can't be loaded
+
 	windowScale float64
 	audioId     engine.Object
 
-	askPanel  *ui.UiAsk
-	answerVal string
+	askPanel   *ui.UiAsk
+	askBusy    bool // asks share askPanel, so they wait for each other
+	answerVal  string
+	askHistory []AskRecord
 
 	// debug
 	debug      bool
@@ -185,9 +211,19 @@ func (p *Game) reset() {
 	p.items = nil
 	p.debugPanel = nil
 	p.askPanel = nil
+	p.askBusy = false
+	p.askHistory = nil
 	p.destroyItems = nil
+	p.tilemaps = nil
+	p.preloaded = nil // holders are destroyed by engine.ReloadScene
+	p.font, p.fonts = nil, nil
 	p.isLoaded = false
+	p.sceneName = ""
 	p.sprs = make(map[string]Sprite)
+	if p.isPaused {
+		p.isPaused = false
+		gco.SetPauseFilter(nil)
+	}
 	timer.OnReload()
 }
 
@@ -228,6 +264,11 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 	if err != nil {
 		panic(err)
 	}
+	cacheKey, ok := resource.(string)
+	if !ok {
+		cacheKey, _ = os.Executable()
+	}
+	assets := useAssetCache(fs, cacheKey)
 
 	var conf Config
 	var proj projConfig
@@ -249,6 +290,7 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 		fullscreen := f.Bool("f", false, "full screen")
 		help := f.Bool("h", false, "show help information")
 		fullscreen2 := f.Bool("fullscreen", false, "server mode")
+		watch := f.Bool("watch", false, "reload changed assets")
 
 		f.String("controller", "", "controller's name")
 		f.Bool("servermode", false, "server mode")
@@ -274,6 +316,7 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 			SetDebug(DbgFlagAll)
 		}
 		conf.FullScreen = conf.FullScreen || *fullscreen2 || *fullscreen
+		conf.Watch = conf.Watch || *watch
 	}
 	if conf.Title == "" {
 		dir, _ := os.Getwd()
@@ -295,6 +338,7 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 
 	v := reflect.ValueOf(game).Elem()
 	g := instance(v)
+	g.assetCache = assets
 	if debugLoad {
 		log.Println("==> StartLoad", resource)
 	}
@@ -319,6 +363,9 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 			// p.sprs[name] = fld (has been set by loadSprite)
 		}
 	}
+	if debugLoad || conf.Watch { // run `gox check` for a full check
+		g.checkProject(conf.Index)
+	}
 	if err := g.endLoad(v, &proj); err != nil {
 		panic(err)
 	}
@@ -326,6 +373,9 @@ func Gopt_Game_Run(game Gamer, resource any, gameConf ...*Config) {
 	if err := g.runLoop(&conf); err != nil {
 		panic(err)
 	}
+	if conf.Watch {
+		g.watchAssets(conf.Index)
+	}
 }
 
 // MouseHitItem returns the topmost item which is hit by mouse.
@@ -403,10 +453,12 @@ func findObjPtr(v reflect.Value, name string, from int) any {
 func (p *Game) startLoad(fs spxfs.Dir, cfg *Config) {
 	p.sounds.init(p)
 	p.inputs.init(p)
-	p.events = make(chan event, 16)
+	p.events = newEventQueue(cfg.EventQueueSize)
 	p.fs = fs
 	p.windowWidth_ = cfg.Width
 	p.windowHeight_ = cfg.Height
+	p.preloadOnStart = cfg.Preload
+	p.initLanguage(cfg)
 }
 
 func (p *Game) canBindSprite(name string) bool {
@@ -419,10 +471,15 @@ func (p *Game) loadSprite(sprite Sprite, name string, gamer reflect.Value) error
 	}
 	var baseDir = "sprites/" + name + "/"
 	var conf spriteConfig
-	err := loadJson(&conf, p.fs, baseDir+"index.json")
+	err := loadIndexJson(&conf, p.fs, baseDir+"index.json")
 	if err != nil {
 		return err
 	}
+	if conf.CostumeAtlas != nil {
+		if err = loadCostumeAtlas(p.fs, baseDir, conf.CostumeAtlas); err != nil {
+			return err
+		}
+	}
 	//
 	// init sprite (field 0)
 	vSpr := reflect.ValueOf(sprite).Elem()
@@ -460,6 +517,7 @@ func (p *Game) loadIndex(g reflect.Value, proj *projConfig) (err error) {
 	p.windowScale = windowScale
 
 	p.debug = proj.Debug
+	gco.SetDebug(proj.Debug)
 	if backdrops := proj.getBackdrops(); len(backdrops) > 0 {
 		p.baseObj.initBackdrops("", backdrops, proj.getBackdropIndex())
 		p.worldWidth_ = proj.Map.Width
@@ -506,7 +564,13 @@ func (p *Game) loadIndex(g reflect.Value, proj *projConfig) (err error) {
 
 	// setup syncSprite's property
 	p.syncSprite = engine.NewBackdropProxy(p, p.getCostumePath(), p.getCostumeRenderScale())
+	p.baseObj.initShader(proj.Shader)
+	p.applyShader(false)
 	p.setupBackdrop()
+	p.setFont(proj.Font)
+	if err = p.loadTilemaps(proj.Tilemaps); err != nil {
+		return
+	}
 	inits := make([]Sprite, 0, len(proj.Zorder))
 	for layer, v := range proj.Zorder {
 		if name, ok := v.(string); ok {
@@ -541,14 +605,26 @@ func (p *Game) loadIndex(g reflect.Value, proj *projConfig) (err error) {
 	if proj.Bgm != "" {
 		p.Play__5(proj.Bgm, &PlayOptions{Action: PlayRewind, Loop: true, Wait: false, Music: true})
 	}
+	p.manifest = p.buildManifest()
 	// game load success
 	p.isLoaded = true
 	return
 }
 
 func (p *Game) setupBackdrop() {
-	var imgW, imgH, dstW, dstH float64
-	imgW, imgH = p.getCostumeSize()
+	imgW, imgH := p.getCostumeSize()
+	dstW, dstH := p.getBackdropRenderSize()
+	scaleX := dstW / imgW
+	scaleY := dstH / imgH
+	p.scale = 1
+	checkUpdateCostume(&p.baseObj)
+	spriteMgr.SetScale(p.syncSprite.GetId(), mathf.NewVec2(scaleX, scaleY))
+}
+
+// getBackdropRenderSize returns the size of the backdrop in the world
+// according to the map mode.
+func (p *Game) getBackdropRenderSize() (dstW, dstH float64) {
+	imgW, imgH := p.getCostumeSize()
 	worldW := float64(p.worldWidth_)
 	worldH := float64(p.worldHeight_)
 	imgRadio := (imgW / imgH)
@@ -578,11 +654,30 @@ func (p *Game) setupBackdrop() {
 			dstH = dstW / imgRadio
 		}
 	}
-	scaleX := dstW / imgW
-	scaleY := dstH / imgH
-	p.scale = 1
-	checkUpdateCostume(&p.baseObj)
-	spriteMgr.SetScale(p.syncSprite.GetId(), mathf.NewVec2(scaleX, scaleY))
+	return
+}
+
+// checkProject reports problems of index.json, index.json of loaded sprites, and
+// scenes and dialogues if files of p.fs can be listed. It's only run in debug or
+// watch mode, so that a game starts quickly.
+func (p *Game) checkProject(index any) {
+	file, ok := index.(string)
+	if index == nil {
+		file, ok = "index.json", true
+	}
+	if !ok {
+		return
+	}
+	sprites := make([]string, 0, len(p.sprs))
+	for name := range p.sprs {
+		sprites = append(sprites, name)
+	}
+	sort.Strings(sprites)
+	scenes, _ := listJSON(p.fs, "scenes")
+	dialogues, _ := listJSON(p.fs, "dialogues")
+	for _, err := range checkProject(p.fs, file, scenes, dialogues, sprites) {
+		log.Println("Warning:", err)
+	}
 }
 
 func (p *Game) endLoad(g reflect.Value, proj *projConfig) (err error) {
@@ -609,6 +704,7 @@ func Gopt_Game_Reload(game Gamer, index any) (err error) {
 	if err = loadProjConfig(&proj, g.fs, index); err != nil {
 		return
 	}
+	g.watchIndex = index
 	gco.OnRestart()
 	err = g.loadIndex(v, &proj)
 	gco.OnInited()
@@ -622,12 +718,20 @@ type specsp = map[string]any
 func (p *Game) addSpecialShape(g reflect.Value, v specsp, inits []Sprite) []Sprite {
 	switch typ := v["type"].(string); typ {
 	case "stageMonitor", "monitor":
-		if sm, err := newMonitor(g, v); err == nil {
-			sm.game = p
-			p.addShape(sm)
+		sm, err := newMonitor(g, v)
+		if err != nil {
+			log.Println("addSpecialShape:", err)
+			break
 		}
+		sm.game = p
+		p.addShape(sm)
 	case "measure":
-		p.addShape(newMeasure(v))
+		m, err := newMeasure(v)
+		if err != nil {
+			log.Println("addSpecialShape:", err)
+			break
+		}
+		p.addShape(m)
 	case "sprites":
 		return p.addStageSprites(g, v, inits)
 	case "sprite":
@@ -780,24 +884,31 @@ func (p *Game) handleEvent(event event) {
 	case *eventKeyDown:
 		p.sinkMgr.doWhenKeyPressed(ev.Key)
 	case *eventStart:
+		if p.preloadOnStart {
+			p.Preload()
+		}
 		p.sinkMgr.doWhenStart()
 	case *eventTimer:
 		p.sinkMgr.doWhenTimer(ev.Time)
+	case *eventReload:
+		p.doReload(ev)
 	}
 }
 
 func (p *Game) fireEvent(ev event) {
-	select {
-	case p.events <- ev:
-	default:
-		log.Println("Event buffer is full. Skip event:", ev)
+	if !p.events.push(ev) && debugEvent {
+		log.Println("Event queue is full. Skip event:", ev)
 	}
 }
 
 func (p *Game) eventLoop(me coroutine.Thread) int {
 	for {
-		var ev event
-		engine.WaitForChan(p.events, &ev)
+		ev, ok := p.events.pop()
+		if !ok {
+			var signal struct{}
+			engine.WaitForChan(p.events.notify, &signal)
+			continue
+		}
 		p.handleEvent(ev)
 	}
 }
@@ -1027,6 +1138,12 @@ func (p *Game) objectPos(obj any) (float64, float64) {
 
 func (p *Game) EraseAll() {
 	extMgr.DestroyAllPens()
+	p.penShapes, p.penPoints, p.penBaked = nil, 0, nil
+	for _, item := range p.items {
+		if sp, ok := item.(*SpriteImpl); ok && sp.penStroke != nil {
+			sp.beginPenStroke()
+		}
+	}
 }
 
 // -----------------------------------------------------------------------------
@@ -1163,10 +1280,15 @@ func (p *Game) goBackLayers(spr *SpriteImpl, n int) {
 }
 func (p *Game) updateRenderLayers() {
 	layer := 0
+	var order []*SpriteImpl
 	for _, item := range p.items {
-		if sp, ok := item.(*SpriteImpl); ok {
-			layer++
-			sp.setLayer(layer)
+		if sp, ok := item.(*SpriteImpl); ok && sp.attach == nil {
+			// attached sprites are ordered relative to their parents
+			order = sp.appendRenderOrder(order[:0])
+			for _, spr := range order {
+				layer++
+				spr.setLayer(layer)
+			}
 		}
 	}
 }
@@ -1309,40 +1431,51 @@ func (p *Game) ResetTimer() {
 	timer.ResetTimer()
 }
 
-// -----------------------------------------------------------------------------
-
-func (p *Game) Ask(msg any) {
-	msgStr, ok := msg.(string)
-	if !ok {
-		msgStr = fmt.Sprint(msg)
+// PauseGame freezes all sprite scripts, the game timer and sprite animations.
+// Stage scripts and UI threads keep running, so a stage script can show a
+// menu and call ResumeGame later.
+func (p *Game) PauseGame() {
+	if p.isPaused {
+		return
+	}
+	if debugInstr {
+		log.Println("PauseGame")
+	}
+	p.isPaused = true
+	gco.SetPauseFilter(func(th coroutine.Thread) bool {
+		return isSprite(th.Obj)
+	})
+	timer.SetPaused(true)
+	p.applyAnimSpeed()
+	if me := gco.Current(); me != nil && isSprite(me.Obj) {
+		engine.WaitNextFrame()
 	}
-	if msgStr == "" {
-		println("ask: msg should not be empty")
+}
+
+// ResumeGame continues the game paused by PauseGame.
+func (p *Game) ResumeGame() {
+	if !p.isPaused {
 		return
 	}
-	p.ask(false, msgStr, func(answer string) {})
+	if debugInstr {
+		log.Println("ResumeGame")
+	}
+	p.isPaused = false
+	gco.SetPauseFilter(nil)
+	timer.SetPaused(false)
+	p.applyAnimSpeed()
 }
 
-func (p *Game) Answer() string {
-	return p.answerVal
+// IsGamePaused reports whether the game is paused by PauseGame.
+func (p *Game) IsGamePaused() bool {
+	return p.isPaused
 }
 
-func (p *Game) ask(isSprite bool, question string, callback func(string)) {
-	if p.askPanel == nil {
-		p.askPanel = ui.NewUiAsk()
-		p.addShape(p.askPanel)
-	}
-	hasAnswer := false
-	p.askPanel.Show(isSprite, question, func(msg string) {
-		p.answerVal = msg
-		callback(msg)
-		hasAnswer = true
-	})
-	for {
-		if hasAnswer {
-			break
+func (p *Game) applyAnimSpeed() {
+	for _, item := range p.items {
+		if sp, ok := item.(*SpriteImpl); ok {
+			sp.applyAnimSpeed()
 		}
-		engine.WaitNextFrame()
 	}
 }
 
@@ -1537,6 +1670,19 @@ func (p *Game) Broadcast__2(msg string, data any, wait bool) {
 	p.doBroadcast(msg, data, wait)
 }
 
+// BroadcastAndWait sends a message, waits until all handlers finish and returns
+// the replies of handlers registered with a result (eg. `onMsg "who", data => distanceTo(...)`).
+func (p *Game) BroadcastAndWait__0(msg string) []any {
+	return p.BroadcastAndWait__1(msg, nil)
+}
+
+func (p *Game) BroadcastAndWait__1(msg string, data any) []any {
+	if debugInstr {
+		log.Println("BroadcastAndWait", msg)
+	}
+	return p.sinkMgr.doWhenIReceiveAndWait(msg, data)
+}
+
 // -----------------------------------------------------------------------------
 
 func (p *Game) setStageMonitor(target string, val string, visible bool) {
@@ -42,12 +42,7 @@ var (
 	extMgr      enginewrap.ExtMgrImpl
 )
 
-var (
-	cachedBounds_ map[string]mathf.Rect2
-)
-
 func (p *Game) OnEngineStart() {
-	cachedBounds_ = make(map[string]mathf.Rect2)
 	onStart := func() {
 		defer engine.CheckPanic()
 		initInput()
@@ -79,8 +74,13 @@ func (p *Game) OnEngineRender(delta float64) {
 	if !p.isRunned {
 		return
 	}
+	p.syncUpdateTilemaps()
 	p.syncUpdateProxy()
 	p.syncUpdatePhysic()
+	p.syncUpdateAnimEvents()
+	if boundsCache_ != nil {
+		boundsCache_.saveLater()
+	}
 }
 
 func (p *Game) syncUpdateLogic() error {
@@ -113,7 +113,20 @@ func (sprite *SpriteImpl) syncCheckInitProxy() {
 		sprite.syncSprite.Name = sprite.name
 		sprite.syncSprite.SetTypeName(sprite.name)
 		sprite.syncSprite.SetVisible(sprite.isVisible)
+		if scale := sprite.getAnimSpeedScale(); scale != 1 {
+			sprite.syncSprite.SetAnimSpeedScale(scale)
+		}
+		if sprite.tint != mathf.NewColor(1, 1, 1, 1) {
+			sprite.syncApplyTint()
+		}
+		if sprite.flipH || sprite.flipV {
+			sprite.syncApplyFlip()
+		}
+		sprite.syncApplyShader()
 		sprite.applyEffects(true)
+		if sprite.isPersistent {
+			engine.SyncSetDontDestroyOnLoad(sprite.syncSprite.GetId())
+		}
 	}
 }
 
@@ -175,6 +188,7 @@ func syncCheckUpdateCostume(p *baseObj) {
 		return
 	}
 	p.isCostumeDirty = false
+	p.animCostume = nil
 	path := p.getCostumePath()
 	renderScale := p.getCostumeRenderScale()
 	rect := p.getCostumeAltasRegion()
@@ -193,6 +207,16 @@ func syncOnAltasChanged(p *baseObj) {
 	p.setMaterialParamsVec4(key, val, true)
 }
 
+func (*Game) syncUpdateAnimEvents() {
+	events := make([]engine.AnimEvent, 0)
+	events = engine.GetAnimEvents(events)
+	for i := range events {
+		if sprite, ok := events[i].Src.Target.(*SpriteImpl); ok && !sprite.HasDestroyed {
+			sprite.syncOnAnimEvent(&events[i])
+		}
+	}
+}
+
 func (*Game) syncUpdatePhysic() {
 	triggers := make([]engine.TriggerEvent, 0)
 	triggers = engine.GetTriggerEvents(triggers)
@@ -220,18 +244,30 @@ func syncInitSpritePhysicInfo(sprite *SpriteImpl, syncProxy *engine.Sprite) {
 	syncProxy.SetCollisionLayer(sprite.collisionLayer)
 	syncProxy.SetCollisionMask(sprite.collisionMask)
 
-	// set trigger & collider
+	syncApplyPhysicShapes(sprite, syncProxy)
+}
+
+// syncApplyPhysicShapes sets trigger & collider shapes. Shapes are configured
+// for the uniform size, and stretched per axis if SetSizeXY is used. A circle
+// can't be stretched, so its radius follows the larger axis.
+func syncApplyPhysicShapes(sprite *SpriteImpl, syncProxy *engine.Sprite) {
+	sx, sy := sprite.getStretch()
+	stretch := func(v mathf.Vec2) mathf.Vec2 {
+		return mathf.NewVec2(v.X*sx, v.Y*sy)
+	}
+	rscale := math.Max(sx, sy)
+
 	switch sprite.colliderType {
 	case physicColliderCircle:
 		syncProxy.SetCollisionEnabled(true)
-		syncProxy.SetColliderCircle(sprite.colliderCenter, math.Max(sprite.colliderRadius, 0.01))
+		syncProxy.SetColliderCircle(stretch(sprite.colliderCenter), math.Max(sprite.colliderRadius*rscale, 0.01))
 	case physicColliderRect:
 		syncProxy.SetCollisionEnabled(true)
-		syncProxy.SetColliderRect(sprite.colliderCenter, sprite.colliderSize)
+		syncProxy.SetColliderRect(stretch(sprite.colliderCenter), stretch(sprite.colliderSize))
 	case physicColliderAuto:
 		center, size := syncGetCostumeBoundByAlpha(sprite, sprite.scale)
 		syncProxy.SetCollisionEnabled(true)
-		syncProxy.SetColliderRect(center, size)
+		syncProxy.SetColliderRect(stretch(center), stretch(size))
 	case physicColliderNone:
 		syncProxy.SetCollisionEnabled(false)
 	}
@@ -239,15 +275,15 @@ func syncInitSpritePhysicInfo(sprite *SpriteImpl, syncProxy *engine.Sprite) {
 	switch sprite.triggerType {
 	case physicColliderCircle:
 		syncProxy.SetTriggerEnabled(true)
-		syncProxy.SetTriggerCircle(sprite.triggerCenter, math.Max(sprite.triggerRadius, 0.01))
+		syncProxy.SetTriggerCircle(stretch(sprite.triggerCenter), math.Max(sprite.triggerRadius*rscale, 0.01))
 		sprite.triggerSize = mathf.NewVec2(sprite.triggerRadius, sprite.triggerRadius)
 	case physicColliderRect:
 		syncProxy.SetTriggerEnabled(true)
-		syncProxy.SetTriggerRect(sprite.triggerCenter, sprite.triggerSize)
+		syncProxy.SetTriggerRect(stretch(sprite.triggerCenter), stretch(sprite.triggerSize))
 	case physicColliderAuto:
 		sprite.triggerCenter, sprite.triggerSize = syncGetCostumeBoundByAlpha(sprite, sprite.scale)
 		syncProxy.SetTriggerEnabled(true)
-		syncProxy.SetTriggerRect(sprite.triggerCenter, sprite.triggerSize)
+		syncProxy.SetTriggerRect(stretch(sprite.triggerCenter), stretch(sprite.triggerSize))
 	case physicColliderNone:
 		syncProxy.SetTriggerEnabled(false)
 	}
@@ -256,19 +292,12 @@ func syncInitSpritePhysicInfo(sprite *SpriteImpl, syncProxy *engine.Sprite) {
 func syncGetCostumeBoundByAlpha(p *SpriteImpl, pscale float64) (mathf.Vec2, mathf.Vec2) {
 	cs := p.costumes[p.costumeIndex_]
 	var rect mathf.Rect2
-	// GetBoundFromAlpha is very slow, so we should cache the result
 	if cs.isAltas() {
 		rect = p.getCostumeAltasRegion()
 		rect.Position.X = 0
 		rect.Position.Y = 0
 	} else {
-		if cache, ok := cachedBounds_[cs.path]; ok {
-			rect = cache
-		} else {
-			assetPath := engine.ToAssetPath(cs.path)
-			rect = engine.SyncGetBoundFromAlpha(assetPath)
-		}
-		cachedBounds_[cs.path] = rect
+		rect = syncGetBoundFromAlpha(cs.path)
 	}
 	scale := pscale / float64(cs.bitmapResolution)
 	// top left
@@ -303,18 +332,25 @@ func calcRenderRotation(p *SpriteImpl) (float64, float64) {
 }
 
 func applyRenderOffset(p *SpriteImpl, cx, cy *float64) {
-	cs := p.costumes[p.costumeIndex_]
-	x, y := -((cs.center.X)/float64(cs.bitmapResolution)+p.pivot.X)*p.scale,
-		((cs.center.Y)/float64(cs.bitmapResolution)-p.pivot.Y)*p.scale
+	w, h := p.renderCostume().getSize()
+	x, y := renderOffset(p, float64(w), float64(h))
+	*cx = *cx + x
+	*cy = *cy + y
+}
+
+// renderOffset returns the offset from the position of a sprite to the center
+// of its costume of size (w, h).
+func renderOffset(p *SpriteImpl, w, h float64) (float64, float64) {
+	cs := p.renderCostume()
+	sx, sy := p.getScaleXY()
+	x, y := -((cs.center.X)/float64(cs.bitmapResolution)+p.pivot.X)*sx,
+		((cs.center.Y)/float64(cs.bitmapResolution)-p.pivot.Y)*sy
 
 	// spx's start point is top left, gdspx's start point is center
 	// so we should remove the offset to make the pivot point is the same
-	w, h := p.getCostumeSize()
-	x = x + float64(w)/2*p.scale
-	y = y - float64(h)/2*p.scale
-
-	*cx = *cx + x
-	*cy = *cy + y
+	x = x + w/2*sx
+	y = y - h/2*sy
+	return x, y
 }
 
 func registerAnimToEngine(spriteName string, animName string, animCfg *aniConfig, costumes []*costume, isCostumeSet bool) {
@@ -333,22 +369,15 @@ func registerAnimToEngine(spriteName string, animName string, animCfg *aniConfig
 		}
 		sb.WriteString(assetPath)
 		sb.WriteString(";")
-		ary := make([]int, 0)
-		if from <= to {
-			for i := from; i <= to; i++ {
-				ary = append(ary, i)
-			}
-		} else {
-			for i := from; i >= to; i-- {
-				ary = append(ary, i)
+		for k, i := range animCfg.frameSeq() {
+			if i < 0 || i >= len(costumes) {
+				log.Panicf("animation key [%s] frame [%d] is out of costumes length [%d]", animName, i, len(costumes))
 			}
-		}
-		for _, i := range ary {
 			costume := costumes[i]
-			sb.WriteString(fmt.Sprintf("%d,%d,%d,%d", costume.posX, costume.posY, costume.width, costume.height))
-			if i != to {
+			if k > 0 {
 				sb.WriteString(",")
 			}
+			sb.WriteString(fmt.Sprintf("%d,%d,%d,%d", costume.posX, costume.posY, costume.width, costume.height))
 		}
 	} else {
 		for i := from; i <= to; i++ {
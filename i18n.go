@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	spxfs "github.com/goplus/spx/v2/fs"
+	"github.com/goplus/spx/v2/internal/engine/platform"
+)
+
+// -------------------------------------------------------------------------------------
+// i18n: string tables are locales/<lang>.json, each maps keys to translated
+// strings, eg. locales/zh.json:
+//
+//	{"Hello!": "你好！", "score": "得分", "You have %d coins": "你有 %d 个金币"}
+//
+// Messages of Say, Think, Ask and Quote, monitor labels and string values shown
+// by monitors (eg. BackdropName) are looked up automatically, and are shown
+// verbatim if they are not in the table. Use T for formatted strings.
+
+type localeTable struct {
+	lang    string
+	strings map[string]string
+}
+
+var locale_ = &localeTable{}
+
+func localeFile(lang string) string {
+	return "locales/" + lang + ".json"
+}
+
+// loadLocale loads the string table of lang, the table of the base language
+// (eg. "zh" for "zh-CN") is used if lang has no table.
+func loadLocale(fs spxfs.Dir, lang string) (*localeTable, error) {
+	candidates := []string{lang}
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		candidates = append(candidates, base)
+	}
+	var err error
+	for _, name := range candidates {
+		var data []byte
+		if data, err = readFile(fs, localeFile(name)); err != nil {
+			continue
+		}
+		table := &localeTable{lang: lang}
+		if err = json.Unmarshal(data, &table.strings); err != nil {
+			return nil, fmt.Errorf("%s: %w", localeFile(name), err)
+		}
+		return table, nil
+	}
+	return nil, err
+}
+
+// tr returns the translation of s, or s itself if it's not in the table.
+func tr(s string) string {
+	if v, ok := locale_.strings[s]; ok {
+		return v
+	}
+	return s
+}
+
+// T returns the translation of key in the current language, it's formatted by
+// fmt.Sprintf if args are specified. Key is returned if it's not in the table.
+func T(key string, args ...any) string {
+	s := tr(key)
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// Language returns the current language, eg. "zh-CN".
+func (p *Game) Language() string {
+	return locale_.lang
+}
+
+// SetLanguage switches to lang (a BCP 47 tag like "en" or "zh-CN"), texts on
+// the stage are updated at once. No text is translated if there is no string
+// table for lang.
+func (p *Game) SetLanguage(lang string) {
+	if debugInstr {
+		log.Println("SetLanguage", lang)
+	}
+	p.setLanguage(lang)
+}
+
+func (p *Game) setLanguage(lang string) {
+	table, err := loadLocale(p.fs, lang)
+	if err != nil {
+		if debugLoad {
+			log.Println("SetLanguage: no string table -", lang, err)
+		}
+		table = &localeTable{lang: lang}
+	}
+	locale_ = table
+}
+
+// initLanguage picks the language of Config, or of the OS/browser.
+func (p *Game) initLanguage(cfg *Config) {
+	lang := cfg.Language
+	if lang == "" {
+		lang = platform.GetLanguage()
+	}
+	if lang == "" {
+		lang = "en"
+	}
+	p.setLanguage(lang)
+}
+
+// -------------------------------------------------------------------------------------
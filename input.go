@@ -17,8 +17,10 @@
 package spx
 
 import (
+	"sync"
 	"time"
 
+	gtime "github.com/goplus/spx/v2/internal/time"
 	gdx "github.com/goplus/spx/v2/pkg/gdspx/pkg/engine"
 	"github.com/realdream-ai/mathf"
 )
@@ -172,6 +174,116 @@ type eventFirer interface {
 
 // -------------------------------------------------------------------------------------
 
+const defaultEventQueueSize = 256
+
+// eventQueue is the pending event queue of a game. Duplicated key/mouse events
+// in the same frame are coalesced, and droppable events are discarded when the
+// queue is full. Priority events (start, key up, mouse up, timer and reload)
+// are never dropped.
+type eventQueue struct {
+	mutex  sync.Mutex
+	items  []queuedEvent
+	size   int
+	notify chan struct{}
+	stats  eventQueueStats
+}
+
+type queuedEvent struct {
+	ev    event
+	frame int64
+}
+
+type eventQueueStats struct {
+	Pending   int   // events waiting to be handled
+	Dropped   int64 // events discarded because the queue was full
+	Coalesced int64 // duplicated events merged in the same frame
+	MaxLen    int   // max length of the queue ever reached
+}
+
+func newEventQueue(size int) *eventQueue {
+	if size <= 0 {
+		size = defaultEventQueueSize
+	}
+	return &eventQueue{size: size, notify: make(chan struct{}, 1)}
+}
+
+func isPriorityEvent(ev event) bool {
+	switch ev.(type) {
+	case *eventStart, *eventKeyUp, *eventLeftButtonUp, *eventTimer, *eventReload:
+		return true
+	}
+	return false
+}
+
+// coalesceKey returns the input source of a key/mouse event and whether it is
+// a press. ok is false for events that can't be coalesced.
+func coalesceKey(ev event) (src Key, down bool, ok bool) {
+	switch v := ev.(type) {
+	case *eventKeyDown:
+		return v.Key, true, true
+	case *eventKeyUp:
+		return v.Key, false, true
+	case *eventLeftButtonDown:
+		return -1, true, true
+	case *eventLeftButtonUp:
+		return -1, false, true
+	}
+	return
+}
+
+// push adds an event to the queue. It returns false if the event is dropped.
+func (p *eventQueue) push(ev event) bool {
+	frame := gtime.Frame()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if src, down, ok := coalesceKey(ev); ok {
+		for i := len(p.items) - 1; i >= 0 && p.items[i].frame == frame; i-- {
+			if src2, down2, ok2 := coalesceKey(p.items[i].ev); ok2 && src2 == src {
+				if down2 == down { // same pending state in this frame
+					p.stats.Coalesced++
+					return true
+				}
+				break
+			}
+		}
+	}
+	if len(p.items) >= p.size && !isPriorityEvent(ev) {
+		p.stats.Dropped++
+		return false
+	}
+	p.items = append(p.items, queuedEvent{ev: ev, frame: frame})
+	if n := len(p.items); n > p.stats.MaxLen {
+		p.stats.MaxLen = n
+	}
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (p *eventQueue) pop() (ev event, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.items) == 0 {
+		return
+	}
+	ev = p.items[0].ev
+	p.items[0] = queuedEvent{}
+	p.items = p.items[1:]
+	return ev, true
+}
+
+func (p *eventQueue) getStats() eventQueueStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	stats := p.stats
+	stats.Pending = len(p.items)
+	return stats
+}
+
+// -------------------------------------------------------------------------------------
+
 type inputManager struct {
 	tempItems []Shape
 	g         *Game
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"reflect"
+	"testing"
+
+	gtime "github.com/goplus/spx/v2/internal/time"
+)
+
+func TestEventQueue(t *testing.T) {
+	var nextFrame event // pushing it moves to the next frame
+	keyDown := func(key Key) event { return &eventKeyDown{Key: key} }
+	keyUp := func(key Key) event { return &eventKeyUp{Key: key} }
+	down, up := &eventLeftButtonDown{}, &eventLeftButtonUp{}
+	tests := []struct {
+		name      string
+		size      int
+		push      []event
+		want      []event // events popped
+		dropped   int64
+		coalesced int64
+	}{
+		{"same key", 0,
+			[]event{keyDown(KeyA), keyDown(KeyA), keyDown(KeyB), keyDown(KeyA)},
+			[]event{keyDown(KeyA), keyDown(KeyB)}, 0, 2},
+		{"press and release", 0,
+			[]event{keyDown(KeyA), keyUp(KeyA), keyDown(KeyA), keyUp(KeyA), keyUp(KeyA)},
+			[]event{keyDown(KeyA), keyUp(KeyA), keyDown(KeyA), keyUp(KeyA)}, 0, 1},
+		{"next frame", 0,
+			[]event{keyDown(KeyA), nextFrame, keyDown(KeyA), down, nextFrame, down},
+			[]event{keyDown(KeyA), keyDown(KeyA), down, down}, 0, 0},
+		{"mouse", 0,
+			[]event{down, down, up, up, down},
+			[]event{down, up, down}, 0, 2},
+		{"full", 2,
+			[]event{keyDown(KeyA), keyDown(KeyB), keyDown(KeyC), down, up, &eventStart{}},
+			[]event{keyDown(KeyA), keyDown(KeyB), up, &eventStart{}}, 2, 0},
+		{"coalesced when full", 1,
+			[]event{keyDown(KeyA), keyDown(KeyA)},
+			[]event{keyDown(KeyA)}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newEventQueue(tt.size)
+			for _, ev := range tt.push {
+				if ev == nil {
+					gtime.Update(1, 0, 0, 0, 0, 30)
+					continue
+				}
+				q.push(ev)
+			}
+			stats := q.getStats()
+			var got []event
+			for {
+				ev, ok := q.pop()
+				if !ok {
+					break
+				}
+				got = append(got, ev)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if stats.Pending != len(tt.want) || stats.Dropped != tt.dropped || stats.Coalesced != tt.coalesced {
+				t.Errorf("got stats %+v, want %d pending, %d dropped and %d coalesced",
+					stats, len(tt.want), tt.dropped, tt.coalesced)
+			}
+		})
+	}
+}
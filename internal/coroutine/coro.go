@@ -29,6 +29,7 @@ type ThreadObj any
 type threadImpl struct {
 	Obj      ThreadObj
 	stopped_ bool
+	paused_  bool
 	frame    int
 	mutex    sync.Mutex // Mutex for this thread's condition variable
 	cond     *sync.Cond // Per-thread condition variable for targeted wake-up
@@ -38,6 +39,10 @@ type threadImpl struct {
 
 	schedFrame     int64
 	schedTimestamp stime.Time
+
+	waitType  int     // what the thread is waiting for, see waitTypeXXX
+	waitTime  float64 // wake-up time for waitTypeTime
+	waitFrame int64   // frame when the wait started
 }
 
 func (p *threadImpl) String() string {
@@ -53,6 +58,9 @@ func (p *threadImpl) Stack() string {
 func (p *threadImpl) Stopped() bool {
 	return p.stopped_
 }
+func (p *threadImpl) Paused() bool {
+	return p.paused_
+}
 
 // Thread represents a coroutine id.
 type Thread = *threadImpl
@@ -71,6 +79,7 @@ type Coroutines struct {
 	onPanic   func(name, stack string)
 	hasInited bool
 	suspended map[Thread]bool
+	pauseIf   func(th Thread) bool // global pause filter, see SetPauseFilter
 	current   Thread
 	mutex     sync.Mutex
 	cond      sync.Cond
@@ -100,6 +109,8 @@ const (
 	waitTypeTime
 	waitTypeMainThread
 	waitTypeYield
+	waitTypeTask // WaitToDo, WaitForChan or Sched
+	waitTypeNone = -1
 )
 
 type WaitJob struct {
@@ -128,6 +139,7 @@ func New(onPanic func(name, stack string)) *Coroutines {
 }
 
 func (p *Coroutines) Sched(me Thread) {
+	p.setWaitInfo(me, waitTypeTask, 0)
 	go func() {
 		p.setWaitStatus(me, waitStatusIdle)
 		p.Resume(me)
@@ -170,9 +182,54 @@ func (p *Coroutines) StopIf(filter func(th Thread) bool) {
 	}
 }
 
+// PauseIf pauses all coroutines matching the filter. A paused coroutine keeps
+// its state, but won't be scheduled until it is resumed by ResumeIf.
+func (p *Coroutines) PauseIf(filter func(th Thread) bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for th := range p.suspended {
+		if filter(th) {
+			th.paused_ = true
+		}
+	}
+	if me := p.Current(); me != nil && filter(me) {
+		me.paused_ = true
+	}
+}
+
+// ResumeIf resumes all paused coroutines matching the filter.
+func (p *Coroutines) ResumeIf(filter func(th Thread) bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for th := range p.suspended {
+		if th.paused_ && filter(th) {
+			th.paused_ = false
+		}
+	}
+}
+
+// SetPauseFilter sets a global pause filter. Coroutines matching the filter
+// are treated as paused, including those created after this call. Passing nil
+// clears the filter.
+func (p *Coroutines) SetPauseFilter(filter func(th Thread) bool) {
+	p.mutex.Lock()
+	p.pauseIf = filter
+	p.mutex.Unlock()
+}
+
+func (p *Coroutines) isPaused(th Thread) bool {
+	if th.paused_ {
+		return true
+	}
+	p.mutex.Lock()
+	filter := p.pauseIf
+	p.mutex.Unlock()
+	return filter != nil && filter(th)
+}
+
 // CreateAndStart creates and executes the new coroutine.
 func (p *Coroutines) CreateAndStart(start bool, tobj ThreadObj, fn func(me Thread) int) Thread {
-	id := &threadImpl{Obj: tobj, frame: p.frame, id: atomic.AddInt64(&p.curThId, 1), schedFrame: -1}
+	id := &threadImpl{Obj: tobj, frame: p.frame, id: atomic.AddInt64(&p.curThId, 1), schedFrame: -1, waitType: waitTypeNone}
 
 	name := ""
 	if tobj != nil {
@@ -213,6 +270,9 @@ func (p *Coroutines) CreateAndStart(start bool, tobj ThreadObj, fn func(me Threa
 			}
 		}()
 		p.setWaitStatus(id, waitStatusAdd)
+		if p.isPaused(id) {
+			p.WaitNextFrame()
+		}
 		fn(id)
 	}()
 	if start {
@@ -258,9 +318,14 @@ func (p *Coroutines) Yield(me Thread) {
 	p.sema.Lock()
 
 	p.setCurrent(me)
+	p.setWaitInfo(me, waitTypeNone, 0)
 	if me.stopped_ {
 		panic(ErrAbortThread)
 	}
+	if p.isPaused(me) {
+		// woken up by a non-scheduled job (eg. WaitToDo), wait until resumed
+		p.WaitNextFrame()
+	}
 }
 
 func (p *Coroutines) isSuspended(me Thread) bool {
@@ -291,6 +356,9 @@ func (p *Coroutines) Resume(me Thread) {
 }
 
 func (p *Coroutines) addWaitJob(job *WaitJob, isFront bool) {
+	if job.Th != nil {
+		p.setWaitInfo(job.Th, job.Type, job.Time)
+	}
 	p.waitMutex.Lock()
 	if isFront {
 		p.curQueue.PushFront(job)
@@ -372,6 +440,10 @@ func (p *Coroutines) WaitMainThread(call func()) {
 	}
 	id := atomic.AddInt64(&p.curId, 1)
 	done := make(chan int)
+	me := p.Current()
+	if me != nil {
+		p.setWaitInfo(me, waitTypeMainThread, 0)
+	}
 	job := &WaitJob{
 		Id:   id,
 		Type: waitTypeMainThread,
@@ -383,9 +455,13 @@ func (p *Coroutines) WaitMainThread(call func()) {
 	// main thread call's priority is higher than other wait jobs
 	p.addWaitJob(job, true)
 	<-done
+	if me != nil {
+		p.setWaitInfo(me, waitTypeNone, 0)
+	}
 }
 func (p *Coroutines) WaitToDo(fn func()) {
 	me := p.Current()
+	p.setWaitInfo(me, waitTypeTask, 0)
 	// This goroutine is necessary since fn() could be a long-running task
 	go func() {
 		fn()
@@ -400,6 +476,7 @@ func (p *Coroutines) WaitToDo(fn func()) {
 
 func WaitForChan[T any](p *Coroutines, done chan T, data *T) {
 	me := p.Current()
+	p.setWaitInfo(me, waitTypeTask, 0)
 	// This goroutine is necessary since <-done could be a long-running task
 	go func() {
 		*data = <-done
@@ -486,6 +563,16 @@ func (p *Coroutines) Update() {
 		task := curQueue.PopFront()
 		stats.TaskCounts++
 
+		if task.Th != nil && !task.Th.stopped_ && task.Type != waitTypeMainThread && p.isPaused(task.Th) {
+			// defer the paused thread, and don't let its wait time elapse
+			if task.Type == waitTypeTime {
+				task.Time += time.DeltaTime()
+			}
+			nextQueue.PushBack(task)
+			stats.TaskProcessing += stime.Since(taskStart).Seconds() * 1000
+			continue
+		}
+
 		switch task.Type {
 		case waitTypeFrame:
 			if task.Frame >= curFrame {
@@ -0,0 +1,81 @@
+package coroutine
+
+import (
+	"sort"
+
+	"github.com/goplus/spx/v2/internal/time"
+)
+
+// ThreadInfo describes a live coroutine, see Coroutines.Threads
+type ThreadInfo struct {
+	Id        int64
+	Name      string
+	Obj       ThreadObj // owner of the thread, eg. a sprite
+	WaitType  string    // "frame", "time", "main", "yield", "task" or "" if running
+	WakeTime  float64   // wake-up time (in seconds since level load) for "time"
+	WaitSince int64     // frame when the wait started
+	Paused    bool
+	Stopped   bool
+	Stack     string // creation stack, only recorded in debug mode
+}
+
+var waitTypeNames = [...]string{
+	waitTypeFrame:      "frame",
+	waitTypeTime:       "time",
+	waitTypeMainThread: "main",
+	waitTypeYield:      "yield",
+	waitTypeTask:       "task",
+}
+
+// SetDebug enables or disables debug mode. In debug mode the creation stack
+// of each coroutine is recorded.
+func (p *Coroutines) SetDebug(v bool) {
+	p.debug = v
+}
+
+func (p *Coroutines) setWaitInfo(me Thread, typ int, wakeTime float64) {
+	if me == nil {
+		return
+	}
+	me.mutex.Lock()
+	me.waitType = typ
+	me.waitTime = wakeTime
+	me.waitFrame = time.Frame()
+	me.mutex.Unlock()
+}
+
+// Threads returns all live coroutines, ordered by creation.
+func (p *Coroutines) Threads() []ThreadInfo {
+	p.waitMutex.Lock()
+	ths := make([]Thread, 0, len(p.waiting))
+	for th := range p.waiting {
+		ths = append(ths, th)
+	}
+	p.waitMutex.Unlock()
+	sort.Slice(ths, func(i, j int) bool {
+		return ths[i].id < ths[j].id
+	})
+
+	infos := make([]ThreadInfo, len(ths))
+	for i, th := range ths {
+		th.mutex.Lock()
+		info := ThreadInfo{
+			Id:        th.id,
+			Name:      th.name,
+			Obj:       th.Obj,
+			WaitSince: th.waitFrame,
+			Stopped:   th.stopped_,
+			Stack:     th.stack,
+		}
+		if th.waitType >= 0 && th.waitType < len(waitTypeNames) {
+			info.WaitType = waitTypeNames[th.waitType]
+			if th.waitType == waitTypeTime {
+				info.WakeTime = th.waitTime
+			}
+		}
+		th.mutex.Unlock()
+		info.Paused = p.isPaused(th)
+		infos[i] = info
+	}
+	return infos
+}
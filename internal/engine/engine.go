@@ -36,6 +36,19 @@ type KeyEvent struct {
 	IsPressed bool
 }
 
+type AnimEventType int
+
+const (
+	AnimEventFrameChanged AnimEventType = iota
+	AnimEventLooped
+)
+
+type AnimEvent struct {
+	Src   *Sprite
+	Type  AnimEventType
+	Frame int64 // current frame of the animation
+}
+
 var (
 	game              IGame
 	triggerEventsTemp []TriggerEvent
@@ -46,6 +59,10 @@ var (
 	keyEvents     []KeyEvent
 	keyMutex      sync.Mutex
 
+	animEventsTemp []AnimEvent
+	animEvents     []AnimEvent
+	animMutex      sync.Mutex
+
 	// time
 	startTimestamp     stime.Time
 	lastTimestamp      stime.Time
@@ -88,6 +105,8 @@ func onStart() {
 	triggerEvents = make([]TriggerEvent, 0)
 	keyEventsTemp = make([]KeyEvent, 0)
 	keyEvents = make([]KeyEvent, 0)
+	animEventsTemp = make([]AnimEvent, 0)
+	animEvents = make([]AnimEvent, 0)
 
 	time.Start(func(scale float64) {
 		platformMgr.SetTimeScale(scale)
@@ -104,6 +123,7 @@ func onUpdate(delta float64) {
 	updateTime(float64(delta))
 	cacheTriggerEvents()
 	cacheKeyEvents()
+	cacheAnimEvents()
 	profiler.MeasureFunctionTime("GameUpdate", func() {
 		game.OnEngineUpdate(delta)
 	})
@@ -169,6 +189,21 @@ func GetKeyEvents(lst []KeyEvent) []KeyEvent {
 	return lst
 }
 
+func cacheAnimEvents() {
+	animMutex.Lock()
+	animEvents = append(animEvents, animEventsTemp...)
+	animMutex.Unlock()
+	animEventsTemp = animEventsTemp[:0]
+}
+
+func GetAnimEvents(lst []AnimEvent) []AnimEvent {
+	animMutex.Lock()
+	lst = append(lst, animEvents...)
+	animEvents = animEvents[:0]
+	animMutex.Unlock()
+	return lst
+}
+
 func CheckPanic() {
 	if e := recover(); e != nil {
 		OnPanic("", "")
@@ -11,6 +11,8 @@ var (
 	assetsDir          = enginePathPrefix + "assets/"
 	configPath         = enginePathPrefix + ".config"
 	engineExtAssetPath = "extasset"
+
+	assetFetch func(relPath string) // see SetAssetCache
 )
 
 type projectConfig struct {
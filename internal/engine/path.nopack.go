@@ -27,7 +27,24 @@ func SetAssetDir(dir string) {
 
 	assetsDir = enginePathPrefix + dir + "/"
 }
+
+// SetAssetCache makes the engine load assets from dir, a local directory that
+// assets are copied into by fetch before their paths are used. It reports
+// whether it's supported.
+func SetAssetCache(dir string, fetch func(relPath string)) bool {
+	if platform.IsWeb() {
+		return false
+	}
+	resMgr.SetLoadMode(true)
+	assetsDir = filepath.ToSlash(dir) + "/"
+	assetFetch = fetch
+	return true
+}
+
 func ToAssetPath(relPath string) string {
+	if assetFetch != nil && relPath != "" {
+		assetFetch(relPath)
+	}
 	replacedPath := replacePathIfInExtAssetDir(relPath, extassetDir, engineExtAssetPath)
 	if replacedPath != "" {
 		return replacedPath
@@ -13,6 +13,12 @@ func SetAssetDir(dir string) {
 	assetsDir = enginePathPrefix + dir + "/"
 }
 
+// SetAssetCache isn't supported in pack mode, where assets are packed with the
+// engine.
+func SetAssetCache(dir string, fetch func(relPath string)) bool {
+	return false
+}
+
 func ToAssetPath(relPath string) string {
 	path := assetsDir + relPath
 	finalPath := strings.ReplaceAll(path, "\\", "/")
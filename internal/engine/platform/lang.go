@@ -0,0 +1,34 @@
+//go:build !js
+// +build !js
+
+package platform
+
+import (
+	"os"
+	"strings"
+)
+
+// GetLanguage returns the language of the user as a BCP 47 tag (eg. "zh-CN"),
+// it's empty if unknown. The locale environment variables are used first, as
+// they are unset for apps started from the GUI on most systems, the locale of
+// the OS is used then.
+func GetLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := os.Getenv(env); lang != "" {
+			return normalizeLanguage(lang)
+		}
+	}
+	return normalizeLanguage(osLocale())
+}
+
+// normalizeLanguage converts a POSIX locale (eg. "zh_CN.UTF-8") to a BCP 47 tag.
+// An empty locale is kept.
+func normalizeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
@@ -0,0 +1,23 @@
+//go:build android
+// +build android
+
+package platform
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// osLocale returns the locale of the system, eg. "zh-CN".
+func osLocale() string {
+	for _, prop := range []string{"persist.sys.locale", "ro.product.locale"} {
+		out, err := exec.Command("getprop", prop).Output()
+		if err != nil {
+			return ""
+		}
+		if locale := strings.TrimSpace(string(out)); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
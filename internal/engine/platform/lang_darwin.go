@@ -0,0 +1,18 @@
+//go:build darwin && !ios
+// +build darwin,!ios
+
+package platform
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// osLocale returns the locale of the user, eg. "zh_CN".
+func osLocale() string {
+	out, err := exec.Command("defaults", "read", "-g", "AppleLocale").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,19 @@
+//go:build js
+// +build js
+
+package platform
+
+import "syscall/js"
+
+// GetLanguage returns the language of the browser as a BCP 47 tag (eg. "zh-CN"),
+// it's empty if unknown.
+func GetLanguage() string {
+	nav := js.Global().Get("navigator")
+	if nav.IsUndefined() {
+		return ""
+	}
+	if lang := nav.Get("language"); lang.Type() == js.TypeString {
+		return lang.String()
+	}
+	return ""
+}
@@ -0,0 +1,13 @@
+//go:build !js && !windows && !android && !(darwin && !ios)
+// +build !js
+// +build !windows
+// +build !android
+// +build !darwin ios
+
+package platform
+
+// osLocale returns the locale of the user. The locale environment variables
+// tell it on Linux, and it's unknown on iOS.
+func osLocale() string {
+	return ""
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package platform
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetUserDefaultLocaleName = syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+
+// osLocale returns the locale of the user, eg. "zh-CN".
+func osLocale() string {
+	const localeNameMaxLength = 85
+	var buf [localeNameMaxLength]uint16
+	n, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buf[0])), localeNameMaxLength)
+	if n == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf[:])
+}
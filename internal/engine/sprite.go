@@ -15,23 +15,23 @@ type Sprite struct {
 	Target  any
 }
 
-func (pself *Sprite) UpdateTexture(path string, renderScale float64) {
+func (pself *Sprite) UpdateTexture(path string, renderScale Vec2) {
 	if path == "" {
 		return
 	}
 	resPath := ToAssetPath(path)
 	pself.PicPath = resPath
 	pself.SetTexture(pself.PicPath)
-	pself.SetRenderScale(NewVec2(renderScale, renderScale))
+	pself.SetRenderScale(renderScale)
 }
-func (pself *Sprite) UpdateTextureAltas(path string, rect2 Rect2, renderScale float64) {
+func (pself *Sprite) UpdateTextureAltas(path string, rect2 Rect2, renderScale Vec2) {
 	if path == "" {
 		return
 	}
 	resPath := ToAssetPath(path)
 	pself.PicPath = resPath
 	pself.SetTextureAltas(pself.PicPath, rect2)
-	pself.SetRenderScale(NewVec2(renderScale, renderScale))
+	pself.SetRenderScale(renderScale)
 }
 
 func (pself *Sprite) UpdateTransform(x, y float64, rot float64, scale64 float64, isSync bool) {
@@ -53,6 +53,14 @@ func (pself *Sprite) UpdateTransform(x, y float64, rot float64, scale64 float64,
 	}
 }
 
+func (pself *Sprite) OnFrameChanged() {
+	animEventsTemp = append(animEventsTemp, AnimEvent{Src: pself, Type: AnimEventFrameChanged, Frame: pself.GetAnimFrame()})
+}
+
+func (pself *Sprite) OnAnimationLooped() {
+	animEventsTemp = append(animEventsTemp, AnimEvent{Src: pself, Type: AnimEventLooped, Frame: pself.GetAnimFrame()})
+}
+
 func (pself *Sprite) OnTriggerEnter(target gdx.ISpriter) {
 	sprite, ok := target.(*Sprite)
 	if ok {
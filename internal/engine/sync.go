@@ -27,6 +27,16 @@ func SyncSetCameraPosition(pos Vec2) {
 	gdx.CameraMgr.SetCameraPosition(NewVec2(pos.X, -pos.Y))
 }
 
+// SyncGetCameraPosition returns the center of the view in SPX coordinates.
+func SyncGetCameraPosition() Vec2 {
+	pos := gdx.CameraMgr.GetCameraPosition()
+	return NewVec2(pos.X, -pos.Y)
+}
+
+func SyncGetCameraZoom() Vec2 {
+	return gdx.CameraMgr.GetCameraZoom()
+}
+
 func SyncScreenToWorld(pos Vec2) Vec2 {
 	camPos := gdx.CameraMgr.GetCameraPosition()
 	camPos.Y *= -1
@@ -42,3 +52,34 @@ func SyncWorldToScreen(pos Vec2) Vec2 {
 func SyncGetBoundFromAlpha(assetPath string) Rect2 {
 	return gdx.ResMgr.GetBoundFromAlpha(assetPath)
 }
+
+func SyncSetDontDestroyOnLoad(obj Object) {
+	gdx.SpriteMgr.SetDontDestroyOnLoad(obj)
+}
+
+func SyncReloadTexture(assetPath string) {
+	gdx.ResMgr.ReloadTexture(assetPath)
+}
+
+// SyncPenDraw draws polylines (in engine coordinates) by a pen of the given
+// width, then moves the pen back to pos and restores its width and state.
+func SyncPenDraw(pen Object, lines [][]Vec2, width, penWidth float64, pos Vec2, isDown bool) {
+	gdx.ExtMgr.PenUp(pen)
+	gdx.ExtMgr.SetPenSizeTo(pen, width)
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		gdx.ExtMgr.MovePenTo(pen, line[0])
+		gdx.ExtMgr.PenDown(pen, false)
+		for _, pt := range line[1:] {
+			gdx.ExtMgr.MovePenTo(pen, pt)
+		}
+		gdx.ExtMgr.PenUp(pen)
+	}
+	gdx.ExtMgr.SetPenSizeTo(pen, penWidth)
+	gdx.ExtMgr.MovePenTo(pen, pos)
+	if isDown {
+		gdx.ExtMgr.PenDown(pen, false)
+	}
+}
@@ -17,22 +17,48 @@ func NewUiNode[T any]() *T {
 	return _ret1
 }
 
-func NewBackdropProxy(obj any, path string, renderScale float64) *Sprite {
+// ZIndexBackdrop is the z index of the backdrop, which is under tilemap layers
+// (negative z indexes) and sprites (positive z indexes).
+const ZIndexBackdrop = -4096
+
+func NewBackdropProxy(obj any, path string, renderScale Vec2) *Sprite {
 	var _ret1 *Sprite
 	WaitMainThread(func() {
 		_ret1 = gdx.CreateEmptySprite[Sprite]()
 		_ret1.Target = obj
-		_ret1.SetZIndex(-1)
+		_ret1.SetZIndex(ZIndexBackdrop)
 		_ret1.DisablePhysic()
 		_ret1.UpdateTexture(path, renderScale)
 	})
 	return _ret1
 }
 
+// SyncPreloadTexture loads a texture, and keeps it in memory by a hidden
+// sprite which is returned.
+func SyncPreloadTexture(assetPath string) *Sprite {
+	holder := gdx.CreateEmptySprite[Sprite]()
+	holder.DisablePhysic()
+	holder.SetVisible(false)
+	holder.SetTexture(assetPath)
+	return holder
+}
+
+// SyncPreloadSound loads a sound by playing it silently.
+func SyncPreloadSound(assetPath string) {
+	obj := gdx.AudioMgr.CreateAudio()
+	gdx.AudioMgr.SetVolume(obj, 0)
+	gdx.AudioMgr.Stop(gdx.AudioMgr.Play(obj, assetPath))
+	gdx.AudioMgr.DestroyAudio(obj)
+}
+
 func ReadAllText(path string) string {
 	return resMgr.ReadAllText(path)
 }
 
+func HasFile(path string) bool {
+	return resMgr.HasFile(path)
+}
+
 // =============== setting ===================
 
 func SetDebugMode(isDebug bool) {
@@ -0,0 +1,64 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/realdream-ai/mathf"
+)
+
+// BBCode converts laid out lines to BBCode of the engine's rich text labels,
+// lines are broken by Layout so the label doesn't wrap them again. font is the
+// path of the font of all texts, empty means the default font. res converts
+// paths of inline images to paths of the engine.
+func BBCode(lines []Line, font string, res func(path string) string) string {
+	var b strings.Builder
+	if font != "" {
+		fmt.Fprintf(&b, "[font=%s]", font)
+	}
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, run := range line.Runs {
+			writeRun(&b, &run, res)
+		}
+	}
+	if font != "" {
+		b.WriteString("[/font]")
+	}
+	return b.String()
+}
+
+func writeRun(b *strings.Builder, run *Run, res func(path string) string) {
+	if run.Image != "" {
+		fmt.Fprintf(b, "[img=%dx%d]%s[/img]", int(run.Width), int(run.Width), res(run.Image))
+		return
+	}
+	var ends []string
+	if run.Size > 0 {
+		fmt.Fprintf(b, "[font_size=%d]", int(run.Size))
+		ends = append(ends, "[/font_size]")
+	}
+	if run.HasColor {
+		fmt.Fprintf(b, "[color=%s]", colorHex(run.Color))
+		ends = append(ends, "[/color]")
+	}
+	if run.Bold {
+		b.WriteString("[b]")
+		ends = append(ends, "[/b]")
+	}
+	b.WriteString(strings.ReplaceAll(run.Text, "[", "[lb]"))
+	for i := len(ends) - 1; i >= 0; i-- {
+		b.WriteString(ends[i])
+	}
+}
+
+// colorHex returns c in the form of #rrggbbaa.
+func colorHex(c mathf.Color) string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", channel(c.R), channel(c.G), channel(c.B), channel(c.A))
+}
+
+func channel(v float64) uint8 {
+	return uint8(mathf.Clamp01f(v)*255 + 0.5)
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text
+
+import "testing"
+
+func TestBBCode(t *testing.T) {
+	res := func(path string) string {
+		return "res://" + path
+	}
+	tests := []struct {
+		name string
+		in   string
+		font string
+		want string
+	}{
+		{"plain", "hello", "", "hello"},
+		{"font", "hello", "res://fonts/a.ttf", "[font=res://fonts/a.ttf]hello[/font]"},
+		{"escape", "a[1]", "", "a[lb]1]"},
+		{"styles", "[size=20][color=#ff0000][b]x[/b][/color][/size]", "",
+			"[font_size=20][color=#ff0000ff][b]x[/b][/color][/font_size]"},
+		{"image", "[img]a.png[/img]", "", "[img=10x10]res://a.png[/img]"},
+		{"lines", "hello world", "", "hello\nworld"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := Layout(ParseRich(tt.in), 40, 10, Heuristic)
+			if got := BBCode(lines, tt.font, res); got != tt.want {
+				t.Errorf("BBCode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package text
+
+import (
+	"image"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// A Measurer measures the width of texts in pixels.
+type Measurer interface {
+	Measure(s string, size float64) float64
+}
+
+// Heuristic measures texts by character classes (a CJK character is twice as
+// wide as an ASCII one), it's used if no font is loaded.
+var Heuristic Measurer = heuristic{}
+
+type heuristic struct{}
+
+func (heuristic) Measure(s string, size float64) float64 {
+	return float64(calculateWordLength(s)) * size / 2
+}
+
+// A Font is a TrueType or OpenType font.
+type Font struct {
+	font  *opentype.Font
+	mu    sync.Mutex
+	faces map[float64]font.Face
+}
+
+// ParseFont parses a TrueType or OpenType font file.
+func ParseFont(data []byte) (*Font, error) {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Font{font: f, faces: make(map[float64]font.Face)}, nil
+}
+
+// face returns the face of size pixels, p.mu must be held. Faces are cached.
+func (p *Font) face(size float64) font.Face {
+	if face, ok := p.faces[size]; ok {
+		return face
+	}
+	face, err := opentype.NewFace(p.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil
+	}
+	p.faces[size] = face
+	return face
+}
+
+// Measure returns the advance width of s.
+func (p *Font) Measure(s string, size float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	face := p.face(size)
+	if face == nil {
+		return Heuristic.Measure(s, size)
+	}
+	return fixedToFloat(font.MeasureString(face, s))
+}
+
+// Metrics returns the ascent and descent of the face of size pixels.
+func (p *Font) Metrics(size float64) (ascent, descent float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	face := p.face(size)
+	if face == nil {
+		return size * 0.8, size * 0.2
+	}
+	m := face.Metrics()
+	return fixedToFloat(m.Ascent), fixedToFloat(m.Descent)
+}
+
+// Draw draws s of size pixels to dst by src, (x, y) is the start point of the
+// baseline.
+func (p *Font) Draw(dst draw.Image, src image.Image, x, y float64, s string, size float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	face := p.face(size)
+	if face == nil {
+		return
+	}
+	d := &font.Drawer{Dst: dst, Src: src, Face: face, Dot: fixed.Point26_6{
+		X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}}
+	d.DrawString(s)
+}
+
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
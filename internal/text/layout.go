@@ -0,0 +1,149 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Run is a span placed on a line, X is the offset from the start of the line.
+type Run struct {
+	Span
+	X, Width float64
+}
+
+// A Line is a line of laid out text.
+type Line struct {
+	Runs  []Run
+	Width float64
+	Size  float64 // the biggest font size of runs, the line height is proportional to it
+}
+
+// isWide reports whether r is a CJK character (or an emoji), lines can break
+// before and after such characters.
+func isWide(r rune) bool {
+	return r >= 0x2e80 || unicode.Is(unicode.Han, r)
+}
+
+type layouter struct {
+	m        Measurer
+	maxWidth float64
+	size     float64 // default font size
+	lines    []Line
+	cur      Line
+	space    *Span // pending space before the next word
+}
+
+// Layout lays out spans into lines no wider than maxWidth, a word wider than
+// maxWidth takes a line by itself. Words are separated by spaces, and CJK
+// characters can be broken anywhere. size is the default font size, and inline
+// images are as big as their font size.
+func Layout(spans []Span, maxWidth, size float64, m Measurer) []Line {
+	p := &layouter{m: m, maxWidth: maxWidth, size: size}
+	for _, sp := range spans {
+		if sp.Image != "" {
+			p.addWord(sp)
+			continue
+		}
+		text := sp.Text
+		for text != "" {
+			r, n := utf8.DecodeRuneInString(text)
+			switch {
+			case r == '\n':
+				p.endLine()
+			case unicode.IsSpace(r):
+				if len(p.cur.Runs) > 0 {
+					space := sp
+					space.Text = " "
+					p.space = &space
+				}
+			case isWide(r):
+				word := sp
+				word.Text = text[:n]
+				p.addWord(word)
+			default:
+				n = strings.IndexFunc(text, func(r rune) bool {
+					return unicode.IsSpace(r) || isWide(r)
+				})
+				if n < 0 {
+					n = len(text)
+				}
+				word := sp
+				word.Text = text[:n]
+				p.addWord(word)
+			}
+			text = text[n:]
+		}
+	}
+	if len(p.cur.Runs) > 0 || len(p.lines) == 0 {
+		p.endLine()
+	}
+	return p.lines
+}
+
+func (p *layouter) sizeOf(sp *Span) float64 {
+	if sp.Size > 0 {
+		return sp.Size
+	}
+	return p.size
+}
+
+func (p *layouter) measure(sp *Span) float64 {
+	size := p.sizeOf(sp)
+	if sp.Image != "" {
+		return size
+	}
+	w := p.m.Measure(sp.Text, size)
+	if sp.Bold {
+		w++ // bold texts are drawn twice, one pixel apart
+	}
+	return w
+}
+
+func (p *layouter) addWord(word Span) {
+	w := p.measure(&word)
+	if p.space != nil {
+		sw := p.measure(p.space)
+		if p.cur.Width+sw+w > p.maxWidth {
+			p.endLine()
+		} else {
+			p.add(*p.space)
+		}
+		p.space = nil
+	}
+	if len(p.cur.Runs) > 0 && p.cur.Width+w > p.maxWidth {
+		p.endLine()
+	}
+	p.add(word)
+}
+
+// add appends sp to the current line, it's merged into the last run if they
+// are in the same style.
+func (p *layouter) add(sp Span) {
+	runs := p.cur.Runs
+	if n := len(runs); n > 0 && sameStyle(&runs[n-1].Span, &sp) {
+		last := &runs[n-1]
+		last.Text += sp.Text
+		last.Width = p.measure(&last.Span)
+		p.cur.Width = last.X + last.Width
+	} else {
+		w := p.measure(&sp)
+		p.cur.Runs = append(runs, Run{Span: sp, X: p.cur.Width, Width: w})
+		p.cur.Width += w
+	}
+	p.cur.Size = max(p.cur.Size, p.sizeOf(&sp))
+}
+
+func sameStyle(a, b *Span) bool {
+	return a.Image == "" && b.Image == "" && a.Bold == b.Bold && a.Size == b.Size &&
+		a.HasColor == b.HasColor && a.Color == b.Color
+}
+
+func (p *layouter) endLine() {
+	if p.cur.Size == 0 {
+		p.cur.Size = p.size
+	}
+	p.lines = append(p.lines, p.cur)
+	p.cur = Line{}
+	p.space = nil
+}
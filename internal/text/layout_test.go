@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	// Heuristic measures an ASCII character as 5 pixels and a CJK one as 10
+	// pixels in size 10
+	tests := []struct {
+		name     string
+		in       string
+		maxWidth float64
+		want     []Line
+	}{
+		{"empty", "", 100, []Line{{Size: 10}}},
+		{"one line", "hello world", 100, []Line{
+			{Runs: []Run{{Span: Span{Text: "hello world"}, Width: 55}}, Width: 55, Size: 10},
+		}},
+		{"wrap", "hello world", 40, []Line{
+			{Runs: []Run{{Span: Span{Text: "hello"}, Width: 25}}, Width: 25, Size: 10},
+			{Runs: []Run{{Span: Span{Text: "world"}, Width: 25}}, Width: 25, Size: 10},
+		}},
+		{"long word", "abcdefghij", 20, []Line{
+			{Runs: []Run{{Span: Span{Text: "abcdefghij"}, Width: 50}}, Width: 50, Size: 10},
+		}},
+		{"spaces", "  a   b ", 100, []Line{
+			{Runs: []Run{{Span: Span{Text: "a b"}, Width: 15}}, Width: 15, Size: 10},
+		}},
+		{"newline", "a\nb", 100, []Line{
+			{Runs: []Run{{Span: Span{Text: "a"}, Width: 5}}, Width: 5, Size: 10},
+			{Runs: []Run{{Span: Span{Text: "b"}, Width: 5}}, Width: 5, Size: 10},
+		}},
+		{"cjk", "你好世界", 25, []Line{
+			{Runs: []Run{{Span: Span{Text: "你好"}, Width: 20}}, Width: 20, Size: 10},
+			{Runs: []Run{{Span: Span{Text: "世界"}, Width: 20}}, Width: 20, Size: 10},
+		}},
+		{"styles", "[b]a[/b] b", 100, []Line{
+			{Runs: []Run{
+				{Span: Span{Text: "a", Bold: true}, Width: 6},
+				{Span: Span{Text: " b"}, X: 6, Width: 10},
+			}, Width: 16, Size: 10},
+		}},
+		{"sizes", "a[size=20]b[/size]", 100, []Line{
+			{Runs: []Run{
+				{Span: Span{Text: "a"}, Width: 5},
+				{Span: Span{Text: "b", Size: 20}, X: 5, Width: 10},
+			}, Width: 15, Size: 20},
+		}},
+		{"images", "[img]a.png[/img][img]b.png[/img] c", 25, []Line{
+			{Runs: []Run{
+				{Span: Span{Image: "a.png"}, Width: 10},
+				{Span: Span{Image: "b.png"}, X: 10, Width: 10},
+			}, Width: 20, Size: 10},
+			{Runs: []Run{{Span: Span{Text: "c"}, Width: 5}}, Width: 5, Size: 10},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Layout(ParseRich(tt.in), tt.maxWidth, 10, Heuristic); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Layout(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
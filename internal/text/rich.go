@@ -0,0 +1,114 @@
+package text
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/realdream-ai/mathf"
+)
+
+// A Span is a piece of text in the same style, or an inline image.
+type Span struct {
+	Text     string
+	Image    string // path of an inline image, Text is empty if it's an image
+	Bold     bool
+	HasColor bool
+	Color    mathf.Color
+	Size     float64 // font size in pixels, 0 means the default size
+}
+
+type richStyle struct {
+	tag      string
+	bold     bool
+	hasColor bool
+	color    mathf.Color
+	size     float64
+}
+
+// ParseRich parses a text with simple markup:
+//
+//	[b]bold[/b]
+//	[color=red]red[/color], [color=#ff8000]orange[/color]
+//	[size=24]big[/size]
+//	[img]emoji/smile.png[/img]
+//
+// Tags can be nested. Unknown or mismatched tags are kept as they are.
+func ParseRich(s string) []Span {
+	var spans []Span
+	var buf strings.Builder
+	stack := []richStyle{{}}
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		spans = append(spans, Span{
+			Text: buf.String(), Bold: top.bold, HasColor: top.hasColor, Color: top.color, Size: top.size})
+		buf.Reset()
+	}
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '[')
+		if i < 0 {
+			buf.WriteString(s)
+			break
+		}
+		buf.WriteString(s[:i])
+		s = s[i:]
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			buf.WriteString(s)
+			break
+		}
+		tag, rest := s[1:end], s[end+1:]
+		if strings.HasPrefix(tag, "/") {
+			if len(stack) > 1 && stack[len(stack)-1].tag == tag[1:] {
+				flush()
+				stack = stack[:len(stack)-1]
+				s = rest
+				continue
+			}
+		} else if tag == "img" {
+			if j := strings.Index(rest, "[/img]"); j >= 0 {
+				flush()
+				top := stack[len(stack)-1]
+				spans = append(spans, Span{Image: strings.TrimSpace(rest[:j]), Size: top.size})
+				s = rest[j+6:]
+				continue
+			}
+		} else if st, ok := pushStyle(stack[len(stack)-1], tag); ok {
+			flush()
+			stack = append(stack, st)
+			s = rest
+			continue
+		}
+		buf.WriteByte('[') // not a tag
+		s = s[1:]
+	}
+	flush()
+	return spans
+}
+
+func pushStyle(top richStyle, tag string) (richStyle, bool) {
+	name, val, _ := strings.Cut(tag, "=")
+	top.tag = name
+	switch name {
+	case "b":
+		top.bold = true
+		return top, val == ""
+	case "color":
+		c, err := mathf.NewColorAny(val)
+		if err != nil {
+			return top, false
+		}
+		top.hasColor, top.color = true, c
+		return top, true
+	case "size":
+		size, err := strconv.ParseFloat(val, 64)
+		if err != nil || size <= 0 {
+			return top, false
+		}
+		top.size = size
+		return top, true
+	}
+	return top, false
+}
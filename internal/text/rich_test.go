@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/realdream-ai/mathf"
+)
+
+func TestParseRich(t *testing.T) {
+	red := mathf.NewColor(1, 0, 0, 1)
+	tests := []struct {
+		name string
+		in   string
+		want []Span
+	}{
+		{"empty", "", nil},
+		{"plain", "hello", []Span{{Text: "hello"}}},
+		{"bold", "a [b]b[/b] c", []Span{{Text: "a "}, {Text: "b", Bold: true}, {Text: " c"}}},
+		{"color", "[color=#ff0000]red[/color]", []Span{{Text: "red", HasColor: true, Color: red}}},
+		{"size", "[size=24]big[/size]", []Span{{Text: "big", Size: 24}}},
+		{"nested", "[size=30][b]x[color=#ff0000]y[/color][/b]z[/size]", []Span{
+			{Text: "x", Bold: true, Size: 30},
+			{Text: "y", Bold: true, HasColor: true, Color: red, Size: 30},
+			{Text: "z", Size: 30},
+		}},
+		{"image", "[size=16]a[img] smile.png [/img][/size]", []Span{
+			{Text: "a", Size: 16}, {Image: "smile.png", Size: 16},
+		}},
+		{"unclosed", "[b]bold", []Span{{Text: "bold", Bold: true}}},
+		{"mismatched close", "[b]x[/color]y[/b]", []Span{{Text: "x[/color]y", Bold: true}}},
+		{"unknown tag", "[i]x[/i]", []Span{{Text: "[i]x[/i]"}}},
+		{"invalid size", "[size=-1]x[/size]", []Span{{Text: "[size=-1]x[/size]"}}},
+		{"bold with value", "[b=1]x", []Span{{Text: "[b=1]x"}}},
+		{"image without end", "[img]x.png", []Span{{Text: "[img]x.png"}}},
+		{"brackets", "a[1] ]b[", []Span{{Text: "a[1] ]b["}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRich(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRich(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package timer
 
 var (
 	gameTimer float64
+	paused    bool
 
 	timestamps     []int64
 	nextTimerIndex int
@@ -20,6 +21,7 @@ func ResetTimer() {
 
 func OnReload() {
 	ResetTimer()
+	paused = false
 	timestamps = timestamps[:0]
 	nextTimerIndex = 0
 }
@@ -56,6 +58,18 @@ func CheckTimerEvent() float64 {
 	return float64(targetTimer) / TIME_PERCISION
 }
 
+// SetPaused freezes or unfreezes the game timer.
+func SetPaused(v bool) {
+	paused = v
+}
+
+func IsPaused() bool {
+	return paused
+}
+
 func OnUpdate(deltaTime float64) {
+	if paused {
+		return
+	}
 	gameTimer += deltaTime
 }
@@ -42,5 +42,21 @@ func (pself *UiAsk) Show(isSprite bool, question string, onCheck func(string)) {
 		uiMgr.SetText(pself.askLabel.GetId(), question)
 	}
 	uiMgr.SetText(pself.input.GetId(), "")
+	uiMgr.SetVisible(pself.input.GetId(), true)
 	uiMgr.SetVisible(pself.GetId(), true)
 }
+
+// ShowText shows msg without the input box, it's hidden by Hide.
+func (pself *UiAsk) ShowText(msg string) {
+	pself.OnCheck = nil
+	uiMgr.SetVisible(pself.askBody.GetId(), true)
+	uiMgr.SetText(pself.askLabel.GetId(), msg)
+	uiMgr.SetVisible(pself.input.GetId(), false)
+	uiMgr.SetVisible(pself.GetId(), true)
+}
+
+// Hide hides the panel without an answer.
+func (pself *UiAsk) Hide() {
+	pself.OnCheck = nil
+	uiMgr.SetVisible(pself.GetId(), false)
+}
@@ -2,7 +2,6 @@ package ui
 
 import (
 	"math"
-	"strings"
 
 	"github.com/goplus/spx/v2/internal/engine"
 	"github.com/goplus/spx/v2/internal/text"
@@ -10,9 +9,13 @@ import (
 )
 
 const (
-	sayMsgSpliteWidth   = 25
+	sayMsgMaxWidth      = 250
 	sayMsgLineHeight    = 26
 	sayMsgDefaultHeight = 77
+
+	// SayFontSize is the default font size of speech bubbles, it's the font
+	// size of rich text labels in engine/theme/ui_say.tres.
+	SayFontSize = 20
 )
 
 type UiSay struct {
@@ -21,6 +24,12 @@ type UiSay struct {
 	labelL *UiNode
 	vboxR  *UiNode
 	labelR *UiNode
+
+	msg      string
+	font     string
+	measurer text.Measurer
+	lines    []text.Line
+	bbcode   string
 }
 
 func NewUiSay() *UiSay {
@@ -36,7 +45,16 @@ func (pself *UiSay) OnStart() {
 	pself.labelR = SyncBindUI[UiNode](pself.GetId(), "VR/BG/Label")
 }
 
-func (pself *UiSay) SetText(winSize mathf.Vec2, pos mathf.Vec2, size mathf.Vec2, msg string) {
+// SetText shows msg (in the markup of text.ParseRich) measured by m. The labels
+// of the bubble are rich text labels, msg is converted to their BBCode in font
+// (an engine path, empty means the default font).
+func (pself *UiSay) SetText(winSize mathf.Vec2, pos mathf.Vec2, size mathf.Vec2, msg, font string, m text.Measurer) {
+	if msg != pself.msg || font != pself.font || m != pself.measurer {
+		pself.msg, pself.font, pself.measurer = msg, font, m
+		pself.lines = LayoutSay(msg, m)
+		pself.bbcode = text.BBCode(pself.lines, font, engine.ToAssetPath)
+	}
+	lines := pself.lines
 	uiMgr.SetScale(pself.GetId(), mathf.NewVec2(windowScale, windowScale))
 	camPos := cameraMgr.GetLocalPosition(pos)
 	x, y := camPos.X, camPos.Y
@@ -47,13 +65,10 @@ func (pself *UiSay) SetText(winSize mathf.Vec2, pos mathf.Vec2, size mathf.Vec2,
 	if !isLeft {
 		label = pself.labelR.GetId()
 	}
-	hasNextLine := strings.ContainsRune(msg, '\n')
-	finalMsg := msg
-	if !hasNextLine {
-		finalMsg = text.SplitLines(msg, sayMsgSpliteWidth)
+	uiHeight := float64(sayMsgDefaultHeight)
+	for _, line := range lines[1:] {
+		uiHeight += sayMsgLineHeight * line.Size / SayFontSize
 	}
-	lineCount := strings.Count(finalMsg, "\n")
-	uiHeight := sayMsgDefaultHeight + float64(lineCount)*sayMsgLineHeight
 	maxYPos := winSize.Y/2 - uiHeight
 	yPos = math.Max(-winSize.Y/2, math.Min(yPos, maxYPos))
 	xPos = math.Max(-winSize.X/2, math.Min(x, winSize.X/2))
@@ -61,5 +76,10 @@ func (pself *UiSay) SetText(winSize mathf.Vec2, pos mathf.Vec2, size mathf.Vec2,
 	uiMgr.SetVisible(pself.vboxL.GetId(), isLeft)
 	uiMgr.SetVisible(pself.vboxR.GetId(), !isLeft)
 	uiMgr.SetPosition(pself.GetId(), WorldToUI(mathf.NewVec2(xPos, yPos)))
-	uiMgr.SetText(label, finalMsg)
+	uiMgr.SetText(label, pself.bbcode)
+}
+
+// LayoutSay lays out msg (in the markup of text.ParseRich) of a speech bubble.
+func LayoutSay(msg string, m text.Measurer) []text.Line {
+	return text.Layout(text.ParseRich(msg), sayMsgMaxWidth, SayFontSize, m)
 }
@@ -45,18 +45,35 @@ type measure struct {
 	panel        *ui.UiMeasure
 }
 
-func newMeasure(v specsp) *measure {
-	size := v["size"].(float64)
-	scale := getSpcspVal(v, "scale", 1.0).(float64)
+func newMeasure(v specsp) (*measure, error) {
+	size, err := getSpcspFloat(v, "size")
+	if err != nil {
+		return nil, err
+	}
+	scale, err := getSpcspFloat(v, "scale", 1.0)
+	if err != nil {
+		return nil, err
+	}
 	text := strconv.FormatFloat(size, 'f', 1, 64)
 	text = strings.TrimSuffix(text, ".0")
-	heading := getSpcspVal(v, "heading", 0.0).(float64)
+	heading, err := getSpcspFloat(v, "heading", 0.0)
+	if err != nil {
+		return nil, err
+	}
 	svgSize := int(size*scale + 0.5 + measureLineWidth)
 	c, err := mathf.NewColorAny(getSpcspVal(v, "color", 0.0))
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("measure: invalid color: %w", err)
 	}
-	pos := mathf.NewVec2(v["x"].(float64), v["y"].(float64))
+	x, err := getSpcspFloat(v, "x")
+	if err != nil {
+		return nil, err
+	}
+	y, err := getSpcspFloat(v, "y")
+	if err != nil {
+		return nil, err
+	}
+	pos := mathf.NewVec2(x, y)
 	panel := ui.NewUiMeasure()
 	meansureObj := &measure{
 		heading:      heading,
@@ -70,7 +87,7 @@ func newMeasure(v specsp) *measure {
 		panel:        panel,
 	}
 	panel.UpdateInfo(meansureObj.pos, size*scale, heading, text, c)
-	return meansureObj
+	return meansureObj, nil
 }
 
 func getSpcspVal(ss specsp, key string, defaultVal ...any) any {
@@ -83,3 +100,37 @@ func getSpcspVal(ss specsp, key string, defaultVal ...any) any {
 	}
 	return v
 }
+
+// getSpcspFloat returns a number property, it's required if defaultVal is
+// absent.
+func getSpcspFloat(ss specsp, key string, defaultVal ...float64) (float64, error) {
+	v, ok := ss[key]
+	if !ok && len(defaultVal) > 0 {
+		return defaultVal[0], nil
+	}
+	if f, ok := v.(float64); ok {
+		return f, nil
+	}
+	return 0, specspError(ss, key, "number", v)
+}
+
+func getSpcspString(ss specsp, key string) (string, error) {
+	if s, ok := ss[key].(string); ok {
+		return s, nil
+	}
+	return "", specspError(ss, key, "string", ss[key])
+}
+
+func getSpcspBool(ss specsp, key string) (bool, error) {
+	if b, ok := ss[key].(bool); ok {
+		return b, nil
+	}
+	return false, specspError(ss, key, "boolean", ss[key])
+}
+
+func specspError(ss specsp, key, kind string, v any) error {
+	if v == nil {
+		return fmt.Errorf("%v: missing property %q", ss["type"], key)
+	}
+	return fmt.Errorf("%v: property %q should be %s, got %v", ss["type"], key, kind, v)
+}
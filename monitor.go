@@ -61,9 +61,29 @@ type Monitor struct {
 "visible": true
 */
 func newMonitor(g reflect.Value, v specsp) (*Monitor, error) {
-	target := v["target"].(string)
-	val := v["val"].(string)
-	name := v["name"].(string)
+	var target, val, name, label string
+	var mode, x, y float64
+	var visible bool
+	var err error
+	for _, prop := range []struct {
+		key string
+		ret *string
+	}{{"target", &target}, {"val", &val}, {"name", &name}, {"label", &label}} {
+		if *prop.ret, err = getSpcspString(v, prop.key); err != nil {
+			return nil, err
+		}
+	}
+	for _, prop := range []struct {
+		key string
+		ret *float64
+	}{{"mode", &mode}, {"x", &x}, {"y", &y}} {
+		if *prop.ret, err = getSpcspFloat(v, prop.key); err != nil {
+			return nil, err
+		}
+	}
+	if visible, err = getSpcspBool(v, "visible"); err != nil {
+		return nil, err
+	}
 	size := 1.0
 	if v["size"] != nil {
 		size, _ = tools.GetFloat(v["size"])
@@ -72,20 +92,15 @@ func newMonitor(g reflect.Value, v specsp) (*Monitor, error) {
 	if eval == nil {
 		return nil, syscall.ENOENT
 	}
-	mode := int(v["mode"].(float64))
 	color, err := mathf.NewColorAny(getSpcspVal(v, "color"))
 	if err != nil {
 		color = mathf.NewColorRGBAi(0x28, 0x9c, 0xfc, 0xff)
 	}
-	label := v["label"].(string)
-	x := v["x"].(float64)
-	y := v["y"].(float64)
-	visible := v["visible"].(bool)
 
 	panel := ui.NewUiMonitor()
 	monitor := &Monitor{
 		target: target, val: val, eval: eval, name: name, size: size,
-		visible: visible, mode: mode, color: color, pos: mathf.NewVec2(x, y), label: label, panel: panel,
+		visible: visible, mode: int(mode), color: color, pos: mathf.NewVec2(x, y), label: label, panel: panel,
 	}
 
 	return monitor, nil
@@ -100,7 +115,7 @@ func (pself *Monitor) onUpdate(delta float64) {
 	pself.panel.ShowAll(pself.mode == 1)
 	pself.panel.UpdateScale(pself.size)
 	pself.panel.UpdatePos(pself.pos)
-	pself.panel.UpdateText(pself.label, val)
+	pself.panel.UpdateText(tr(pself.label), val)
 	pself.panel.UpdateColor(pself.color)
 }
 
@@ -163,6 +178,9 @@ func buildMonitorEval(g reflect.Value, t, val string) func() string {
 					if succ {
 						return fmt.Sprintf("%.2f", f32Val)
 					}
+					if s, ok := result.(string); ok && name == "BackdropName" {
+						return tr(s) // backdrop names are texts of the project
+					}
 					return fmt.Sprint(result)
 				}
 			}
@@ -0,0 +1,467 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"image"
+	"log"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/goplus/spx/v2/internal/text"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// pen shapes: besides being drawn by the engine, everything drawn by pens is
+// recorded, so that ClearPen can erase the drawings of one sprite and the pen
+// layer can be exported as an image. If too many points are recorded, older
+// shapes are rasterized into an image of each sprite to bound the memory used.
+
+const (
+	penPointsMax  = 1 << 16 // points of pen shapes kept before rasterized
+	penTextMaxRes = 32      // max pixels of the font size to render texts of DrawText
+)
+
+type penShape struct {
+	owner  *SpriteImpl
+	points []mathf.Vec2 // in world coordinates
+	color  mathf.Color
+	width  float64
+	fill   bool // points is a polygon to fill
+
+	stamp *costume   // points[0] is the center of the stamp
+	scale mathf.Vec2 // render scale of the stamp, negative means mirrored
+	rot   float64    // rotation of the stamp in degrees
+}
+
+func (p *SpriteImpl) getPenWidth() float64 {
+	if p.penWidth > 0 {
+		return p.penWidth
+	}
+	return 1
+}
+
+// penPos returns the position of the pen when this sprite is at (x, y).
+func (p *SpriteImpl) penPos(x, y float64) (float64, float64) {
+	applyRenderOffset(p, &x, &y)
+	return x, y
+}
+
+func (p *SpriteImpl) addPenShape(shape *penShape) {
+	shape.owner = p
+	p.g.penShapes = append(p.g.penShapes, shape)
+	p.g.addPenPoints(len(shape.points))
+}
+
+// addPenPoints is called when n points are recorded.
+func (p *Game) addPenPoints(n int) {
+	p.penPoints += n
+	if p.penPoints > penPointsMax {
+		p.bakePenShapes()
+	}
+}
+
+// bakePenShapes rasterizes the oldest shapes until half of penPointsMax points
+// are recorded. A stroke that is still being drawn is continued by a new one.
+func (p *Game) bakePenShapes() {
+	w, h := p.worldSize_()
+	if p.penBaked == nil {
+		p.penBaked = make(map[*SpriteImpl]*image.RGBA)
+	}
+	rs := make(map[*SpriteImpl]*rasterizer)
+	n := 0
+	var strokes []*penShape
+	for ; n < len(p.penShapes) && p.penPoints > penPointsMax/2; n++ {
+		shape := p.penShapes[n]
+		owner := shape.owner
+		r := rs[owner]
+		if r == nil {
+			r = newRasterizer(p, w, h, 0, 0)
+			if img := p.penBaked[owner]; img != nil {
+				r.img = img
+			}
+			p.penBaked[owner], rs[owner] = r.img, r
+		}
+		r.drawPenShape(shape)
+		p.penPoints -= len(shape.points)
+		if owner.penStroke == shape {
+			last := shape.points[len(shape.points)-1]
+			owner.penStroke = &penShape{
+				owner: owner, points: []mathf.Vec2{last}, color: shape.color, width: shape.width,
+			}
+			strokes = append(strokes, owner.penStroke)
+			p.penPoints++
+		}
+	}
+	p.penShapes = append(strokes, p.penShapes[n:]...)
+}
+
+// drawPenBaked draws rasterized pen shapes.
+func (p *rasterizer) drawPenBaked(g *Game) {
+	white := mathf.NewColor(1, 1, 1, 1)
+	for _, img := range g.penBaked {
+		p.drawImage(img, img.Rect, mathf.Vec2{}, 0, 1, 1, white)
+	}
+}
+
+// beginPenStroke starts recording the moves of a pen that is down.
+func (p *SpriteImpl) beginPenStroke() {
+	x, y := p.penPos(p.x, p.y)
+	p.penStroke = &penShape{
+		points: []mathf.Vec2{mathf.NewVec2(x, y)},
+		color:  p.penColor, width: p.getPenWidth(),
+	}
+	p.addPenShape(p.penStroke)
+}
+
+// restartPenStroke is called when color or size of a pen that is down changes.
+func (p *SpriteImpl) restartPenStroke() {
+	if p.penStroke != nil {
+		p.beginPenStroke()
+	}
+}
+
+func (p *SpriteImpl) removePenShapes() {
+	p.penStroke = nil
+	g := p.g
+	g.penShapes = slices.DeleteFunc(g.penShapes, func(shape *penShape) bool {
+		if shape.owner == p {
+			g.penPoints -= len(shape.points)
+			return true
+		}
+		return false
+	})
+	delete(g.penBaked, p)
+}
+
+// drawPenLines draws polylines in world coordinates by the pen of this sprite.
+func (p *SpriteImpl) drawPenLines(lines [][]mathf.Vec2, width float64) {
+	p.checkOrCreatePen()
+	pen := *p.penObj
+	engineLines := make([][]mathf.Vec2, len(lines))
+	for i, line := range lines {
+		engineLine := make([]mathf.Vec2, len(line))
+		for j, pt := range line {
+			engineLine[j] = mathf.NewVec2(pt.X, -pt.Y)
+		}
+		engineLines[i] = engineLine
+	}
+	x, y := p.penPos(p.x, p.y)
+	penWidth, isDown := p.getPenWidth(), p.isPenDown
+	engine.WaitMainThread(func() {
+		engine.SyncPenDraw(pen, engineLines, width, penWidth, mathf.NewVec2(x, -y), isDown)
+	})
+}
+
+func (p *SpriteImpl) drawPenPolygon(points []mathf.Vec2, fill bool) {
+	if len(points) < 2 {
+		return
+	}
+	if fill {
+		p.drawPenLines(fillLines(points), 1)
+		p.addPenShape(&penShape{points: points, color: p.penColor, fill: true})
+	}
+	outline := append(slices.Clone(points), points[0])
+	p.drawPenLines([][]mathf.Vec2{outline}, p.getPenWidth())
+	p.addPenShape(&penShape{points: outline, color: p.penColor, width: p.getPenWidth()})
+}
+
+// fillLines returns horizontal lines of width 1 that cover a polygon.
+func fillLines(poly []mathf.Vec2) (lines [][]mathf.Vec2) {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, pt := range poly {
+		minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+	}
+	for y := math.Floor(minY) + 0.5; y < maxY; y++ {
+		xs := polygonCrossings(poly, y)
+		for i := 0; i+1 < len(xs); i += 2 {
+			lines = append(lines, []mathf.Vec2{mathf.NewVec2(xs[i], y), mathf.NewVec2(xs[i+1], y)})
+		}
+	}
+	return
+}
+
+// polygonCrossings returns sorted x of the edges of a polygon crossing the
+// horizontal line at y, pairs of them are inside the polygon (even-odd rule).
+func polygonCrossings(poly []mathf.Vec2, y float64) []float64 {
+	var xs []float64
+	n := len(poly)
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%n]
+		if (a.Y <= y) != (b.Y <= y) {
+			xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+		}
+	}
+	sort.Float64s(xs)
+	return xs
+}
+
+// textLines returns horizontal lines that draw text in font f, (x, y) is the
+// start point of the baseline and size is the font size. The text is rendered
+// in at most penTextMaxRes pixels high and scaled up, lines are as wide as a
+// scaled pixel.
+func textLines(f *text.Font, x, y float64, s string, size float64) (lines [][]mathf.Vec2, width float64) {
+	res := math.Min(math.Ceil(size), penTextMaxRes)
+	w := int(math.Ceil(f.Measure(s, res)))
+	if w <= 0 || res <= 0 {
+		return
+	}
+	ascent, descent := f.Metrics(res)
+	asc := int(math.Ceil(ascent))
+	h := asc + int(math.Ceil(descent))
+	img := image.NewAlpha(image.Rect(0, 0, w, h))
+	f.Draw(img, image.Opaque, 0, float64(asc), s, res)
+
+	scale := size / res
+	filled := func(c, row int) bool { return img.AlphaAt(c, row).A >= 0x80 }
+	for row := 0; row < h; row++ {
+		ly := y + (float64(asc-row)-0.5)*scale
+		for c := 0; c < w; {
+			if !filled(c, row) {
+				c++
+				continue
+			}
+			start := c
+			for c < w && filled(c, row) {
+				c++
+			}
+			lines = append(lines, []mathf.Vec2{
+				mathf.NewVec2(x+(float64(start)+0.5)*scale, ly),
+				mathf.NewVec2(x+(float64(c)-0.5)*scale, ly),
+			})
+		}
+	}
+	return lines, scale
+}
+
+// -------------------------------------------------------------------------------------
+
+// DrawLine draws a line from (x1, y1) to (x2, y2) with the pen color and size.
+func (p *SpriteImpl) DrawLine(x1, y1, x2, y2 float64) {
+	if debugInstr {
+		log.Println("DrawLine", p.name, x1, y1, x2, y2)
+	}
+	line := []mathf.Vec2{mathf.NewVec2(x1, y1), mathf.NewVec2(x2, y2)}
+	p.drawPenLines([][]mathf.Vec2{line}, p.getPenWidth())
+	p.addPenShape(&penShape{points: line, color: p.penColor, width: p.getPenWidth()})
+}
+
+func (p *SpriteImpl) DrawCircle__0(x, y, radius float64) {
+	p.DrawCircle__1(x, y, radius, false)
+}
+
+// DrawCircle__1 draws a circle centered at (x, y), and fills it with the pen
+// color if fill is true.
+func (p *SpriteImpl) DrawCircle__1(x, y, radius float64, fill bool) {
+	if debugInstr {
+		log.Println("DrawCircle", p.name, x, y, radius, fill)
+	}
+	n := int(mathf.Clamp(2*math.Pi*radius/4, 16, 128))
+	points := make([]mathf.Vec2, n)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		points[i] = mathf.NewVec2(x+radius*cos, y+radius*sin)
+	}
+	p.drawPenPolygon(points, fill)
+}
+
+func (p *SpriteImpl) DrawRect__0(x, y, width, height float64) {
+	p.DrawRect__1(x, y, width, height, false)
+}
+
+// DrawRect__1 draws a rectangle whose bottom left corner is (x, y), and fills
+// it with the pen color if fill is true.
+func (p *SpriteImpl) DrawRect__1(x, y, width, height float64, fill bool) {
+	if debugInstr {
+		log.Println("DrawRect", p.name, x, y, width, height, fill)
+	}
+	p.drawPenPolygon([]mathf.Vec2{
+		mathf.NewVec2(x, y), mathf.NewVec2(x+width, y),
+		mathf.NewVec2(x+width, y+height), mathf.NewVec2(x, y+height),
+	}, fill)
+}
+
+func (p *SpriteImpl) DrawPolygon__0(points []float64) {
+	p.DrawPolygon__1(points, false)
+}
+
+// DrawPolygon__1 draws a polygon, points are x, y pairs of its vertices. It
+// fills the polygon with the pen color if fill is true.
+func (p *SpriteImpl) DrawPolygon__1(points []float64, fill bool) {
+	if debugInstr {
+		log.Println("DrawPolygon", p.name, points, fill)
+	}
+	if len(points)%2 != 0 {
+		log.Println("DrawPolygon: points should be x, y pairs")
+	}
+	poly := make([]mathf.Vec2, len(points)/2)
+	for i := range poly {
+		poly[i] = mathf.NewVec2(points[2*i], points[2*i+1])
+	}
+	p.drawPenPolygon(poly, fill)
+}
+
+func (p *SpriteImpl) DrawText__0(x, y float64, text string) {
+	p.DrawText__1(x, y, text, 13)
+}
+
+// DrawText__1 draws text with the pen color in the sprite font (or the project
+// font), (x, y) is the start point of the baseline and size is the font size.
+func (p *SpriteImpl) DrawText__1(x, y float64, text string, size float64) {
+	if debugInstr {
+		log.Println("DrawText", p.name, x, y, text, size)
+	}
+	lines, width := textLines(p.rasterFont(), x, y, text, size)
+	if len(lines) == 0 {
+		return
+	}
+	p.drawPenLines(lines, width)
+	for _, line := range lines {
+		p.addPenShape(&penShape{points: line, color: p.penColor, width: width})
+	}
+}
+
+// ClearPen erases everything drawn by the pen of this sprite, drawings of
+// other sprites are kept.
+func (p *SpriteImpl) ClearPen() {
+	if debugInstr {
+		log.Println("ClearPen", p.name)
+	}
+	if p.penObj == nil {
+		return
+	}
+	p.destroyPen()
+	p.checkOrCreatePen()
+	extMgr.SetPenColorTo(*p.penObj, p.penColor)
+	if p.penWidth > 0 {
+		extMgr.SetPenSizeTo(*p.penObj, p.penWidth)
+	}
+	if p.isPenDown {
+		p.PenDown()
+	}
+}
+
+// -------------------------------------------------------------------------------------
+
+// PenImage returns the pen layer in the size of the world.
+func (p *Game) PenImage() image.Image {
+	w, h := p.worldSize_()
+	r := newRasterizer(p, w, h, 0, 0)
+	r.drawPenBaked(p)
+	for _, shape := range p.penShapes {
+		r.drawPenShape(shape)
+	}
+	return r.img
+}
+
+func (p *rasterizer) drawPenShape(shape *penShape) {
+	switch {
+	case shape.stamp != nil:
+		p.drawStamp(shape)
+	case shape.fill:
+		p.drawFill(shape)
+	default:
+		p.drawStroke(shape)
+	}
+}
+
+func (p *rasterizer) drawFill(shape *penShape) {
+	poly := make([]mathf.Vec2, len(shape.points))
+	for i, pt := range shape.points {
+		poly[i] = p.toPixel(pt)
+	}
+	bounds := p.img.Rect
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		xs := polygonCrossings(poly, float64(y)+0.5)
+		for i := 0; i+1 < len(xs); i += 2 {
+			x0 := max(int(math.Ceil(xs[i]-0.5)), bounds.Min.X)
+			x1 := min(int(math.Floor(xs[i+1]-0.5)), bounds.Max.X-1)
+			for x := x0; x <= x1; x++ {
+				p.blend(x, y, shape.color)
+			}
+		}
+	}
+}
+
+// drawStroke draws a polyline with round joins. Coverage is computed for the
+// whole polyline first, so joins of transparent strokes are not blended twice.
+func (p *rasterizer) drawStroke(shape *penShape) {
+	if len(shape.points) == 0 {
+		return
+	}
+	r := math.Max(shape.width/2, 0.5)
+	pts := make([]mathf.Vec2, len(shape.points))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for i, pt := range shape.points {
+		pts[i] = p.toPixel(pt)
+		minX, maxX = math.Min(minX, pts[i].X), math.Max(maxX, pts[i].X)
+		minY, maxY = math.Min(minY, pts[i].Y), math.Max(maxY, pts[i].Y)
+	}
+	rect := image.Rect(int(math.Floor(minX-r)), int(math.Floor(minY-r)),
+		int(math.Ceil(maxX+r))+1, int(math.Ceil(maxY+r))+1).Intersect(p.img.Rect)
+	if rect.Empty() {
+		return
+	}
+	mask := image.NewAlpha(rect)
+	if len(pts) == 1 {
+		pts = append(pts, pts[0])
+	}
+	for i := 0; i+1 < len(pts); i++ {
+		a, b := pts[i], pts[i+1]
+		seg := image.Rect(int(math.Floor(math.Min(a.X, b.X)-r)), int(math.Floor(math.Min(a.Y, b.Y)-r)),
+			int(math.Ceil(math.Max(a.X, b.X)+r))+1, int(math.Ceil(math.Max(a.Y, b.Y)+r))+1).Intersect(rect)
+		for y := seg.Min.Y; y < seg.Max.Y; y++ {
+			for x := seg.Min.X; x < seg.Max.X; x++ {
+				c := mathf.NewVec2(float64(x)+0.5, float64(y)+0.5)
+				if distToSegment(c, a, b) <= r {
+					mask.Pix[mask.PixOffset(x, y)] = 0xff
+				}
+			}
+		}
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if mask.Pix[mask.PixOffset(x, y)] != 0 {
+				p.blend(x, y, shape.color)
+			}
+		}
+	}
+}
+
+func distToSegment(c, a, b mathf.Vec2) float64 {
+	ab, ac := b.Sub(a), c.Sub(a)
+	l2 := ab.X*ab.X + ab.Y*ab.Y
+	t := 0.0
+	if l2 > 0 {
+		t = mathf.Clamp01f((ac.X*ab.X + ac.Y*ab.Y) / l2)
+	}
+	d := ac.Sub(ab.Mulf(t))
+	return math.Hypot(d.X, d.Y)
+}
+
+func (p *rasterizer) drawStamp(shape *penShape) {
+	src, region := p.costumeImage(shape.stamp)
+	if src == nil {
+		return
+	}
+	p.drawImage(src, region, shape.points[0], shape.rot, shape.scale.X, shape.scale.Y, mathf.NewColor(1, 1, 1, 1))
+}
+
+// -------------------------------------------------------------------------------------
@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// preloading: textures and sounds are loaded lazily by the engine when they
+// are used first. Preload loads them in advance, a few per frame, and fires
+// OnLoadProgress events so that a loading bar can be shown.
+
+const preloadBatch = 8 // assets loaded per frame
+
+// assetManifest lists assets used by the loaded sprites, backdrops, tilemaps
+// and sounds.
+type assetManifest struct {
+	assets []string        // images first, then sounds
+	bounds map[string]bool // images whose alpha bounds are used by colliders
+}
+
+func (p *Game) buildManifest() *assetManifest {
+	m := &assetManifest{bounds: make(map[string]bool)}
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			m.assets = append(m.assets, file)
+		}
+	}
+	addCostumes := func(obj *baseObj) {
+		for _, cs := range obj.costumes {
+			add(cs.path)
+			if !cs.isAltas() {
+				m.bounds[cs.path] = true
+			}
+		}
+	}
+	addCostumes(&p.baseObj)
+	for _, name := range sortedKeys(p.sprs) {
+		if spr := spriteOf(p.sprs[name]); spr != nil {
+			addCostumes(&spr.baseObj)
+		}
+	}
+	for _, tm := range p.tilemaps {
+		for _, ts := range tm.tilesets {
+			add(ts.path)
+		}
+	}
+	sounds := make([]string, 0, len(p.sounds.audios))
+	for _, media := range p.sounds.audios {
+		sounds = append(sounds, media.Path)
+	}
+	sort.Strings(sounds)
+	for _, file := range sounds {
+		add(file)
+	}
+	return m
+}
+
+// Preload loads images and sounds (paths relative to the assets directory) in
+// advance, all assets of the loaded sprites, backdrops, tilemaps and sounds are
+// loaded if no path is specified. It returns after all assets are loaded.
+func (p *Game) Preload(paths ...string) {
+	if debugInstr {
+		log.Println("Preload", paths)
+	}
+	bounds := make(map[string]bool)
+	if m := p.manifest; m != nil {
+		if len(paths) == 0 {
+			paths = m.assets
+		}
+		bounds = m.bounds
+	}
+	n := len(paths)
+	for i := 0; i < n; i += preloadBatch {
+		batch := paths[i:min(i+preloadBatch, n)]
+		engine.WaitMainThread(func() {
+			for _, file := range batch {
+				p.syncPreload(file, bounds[file])
+			}
+		})
+		p.sinkMgr.doWhenLoadProgress(float64(i+len(batch)) * 100 / float64(n))
+		engine.WaitNextFrame()
+	}
+	if n == 0 {
+		p.sinkMgr.doWhenLoadProgress(100)
+	}
+	engine.WaitMainThread(getBoundsCache().save)
+}
+
+func (p *Game) syncPreload(file string, hasBounds bool) {
+	if _, ok := p.preloaded[file]; ok {
+		return
+	}
+	var holder *engine.Sprite
+	switch strings.ToLower(path.Ext(file)) {
+	case ".png", ".jpg", ".jpeg", ".svg", ".webp":
+		holder = engine.SyncPreloadTexture(engine.ToAssetPath(file))
+		if hasBounds {
+			syncGetBoundFromAlpha(file)
+		}
+	case ".wav", ".mp3", ".ogg":
+		engine.SyncPreloadSound(engine.ToAssetPath(file))
+	default:
+		log.Println("Preload: unknown asset type -", file)
+	}
+	if p.preloaded == nil {
+		p.preloaded = make(map[string]*engine.Sprite)
+	}
+	p.preloaded[file] = holder
+}
+
+// -------------------------------------------------------------------------------------
+// boundsCache caches alpha bounds of images (GetBoundFromAlpha is very slow) in
+// memory, and on disk if the images are local files. A disk entry is valid if
+// the size and modification time of the image are not changed. The disk cache
+// is loaded when it's used first, and saved after Preload, or a while after new
+// bounds are computed. Only the latest used boundsCacheMax entries are kept.
+
+const (
+	boundsCacheMax  = 4096
+	boundsSaveDelay = 3 * time.Second
+)
+
+type boundsCache struct {
+	file    string                  // where the disk cache is saved, empty if no disk cache
+	mem     map[string]mathf.Rect2  // asset path => bounds
+	disk    map[string]*boundsEntry // absolute path of image => entry
+	dirty   bool
+	dirtyAt time.Time // when it becomes dirty
+	saveMu  sync.Mutex
+}
+
+type boundsEntry struct {
+	Size    int64      `json:"size"`
+	ModTime int64      `json:"mtime"`
+	Used    int64      `json:"used"` // when it's used last, in unix seconds
+	Rect    [4]float64 `json:"rect"` // x, y, w, h
+}
+
+var (
+	boundsCache_     *boundsCache
+	boundsCacheOnce_ sync.Once
+)
+
+// getBoundsCache returns the bounds cache, which is loaded at the first call.
+func getBoundsCache() *boundsCache {
+	boundsCacheOnce_.Do(func() {
+		boundsCache_ = newBoundsCache()
+	})
+	return boundsCache_
+}
+
+func newBoundsCache() *boundsCache {
+	p := &boundsCache{
+		mem:  make(map[string]mathf.Rect2),
+		disk: make(map[string]*boundsEntry),
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		p.file = filepath.Join(dir, "spx", "bounds.json")
+		if data, err := os.ReadFile(p.file); err == nil {
+			json.Unmarshal(data, &p.disk)
+		}
+	}
+	return p
+}
+
+// stat returns the key and the file info (without bounds) of an image on disk.
+func (p *boundsCache) stat(file string) (string, *boundsEntry) {
+	if p.file == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(engine.ToAssetPath(file))
+	if err != nil {
+		return "", nil
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", nil
+	}
+	return abs, &boundsEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+}
+
+func (p *boundsCache) get(file string) (mathf.Rect2, bool) {
+	if rect, ok := p.mem[file]; ok {
+		return rect, true
+	}
+	key, st := p.stat(file)
+	if e := p.disk[key]; st != nil && e != nil && e.Size == st.Size && e.ModTime == st.ModTime {
+		e.Used = time.Now().Unix()
+		rect := mathf.NewRect2(e.Rect[0], e.Rect[1], e.Rect[2], e.Rect[3])
+		p.mem[file] = rect
+		return rect, true
+	}
+	return mathf.Rect2{}, false
+}
+
+func (p *boundsCache) put(file string, rect mathf.Rect2) {
+	p.mem[file] = rect
+	if key, st := p.stat(file); st != nil {
+		st.Used = time.Now().Unix()
+		st.Rect = [4]float64{rect.Position.X, rect.Position.Y, rect.Size.X, rect.Size.Y}
+		p.disk[key] = st
+		if !p.dirty {
+			p.dirty, p.dirtyAt = true, time.Now()
+		}
+	}
+}
+
+// remove removes bounds of an image from memory, the disk entry becomes invalid
+// by itself if the image is changed.
+func (p *boundsCache) remove(file string) {
+	delete(p.mem, file)
+}
+
+// prune removes the least recently used entries if there are too many.
+func (p *boundsCache) prune() {
+	n := len(p.disk) - boundsCacheMax
+	if n <= 0 {
+		return
+	}
+	keys := make([]string, 0, len(p.disk))
+	for key := range p.disk {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return p.disk[keys[i]].Used < p.disk[keys[j]].Used
+	})
+	for _, key := range keys[:n] {
+		delete(p.disk, key)
+	}
+}
+
+// saveLater saves the disk cache if it's dirty for a while, it's called every
+// frame so that bounds computed by a burst of cache misses are saved at once.
+func (p *boundsCache) saveLater() {
+	if p.dirty && time.Since(p.dirtyAt) >= boundsSaveDelay {
+		p.save()
+	}
+}
+
+// save saves the disk cache if it's dirty. The file is written in background.
+func (p *boundsCache) save() {
+	if !p.dirty {
+		return
+	}
+	p.dirty = false
+	p.prune()
+	data, err := json.Marshal(p.disk)
+	if err != nil {
+		log.Println("boundsCache: save failed -", err)
+		return
+	}
+	go func() {
+		p.saveMu.Lock()
+		defer p.saveMu.Unlock()
+		err := os.MkdirAll(filepath.Dir(p.file), 0755)
+		if err == nil {
+			err = os.WriteFile(p.file, data, 0644)
+		}
+		if err != nil && debugLoad {
+			log.Println("boundsCache: save failed -", err)
+		}
+	}()
+}
+
+// syncGetBoundFromAlpha returns the alpha bounds of an image, which is computed
+// only once.
+func syncGetBoundFromAlpha(file string) mathf.Rect2 {
+	cache := getBoundsCache()
+	if rect, ok := cache.get(file); ok {
+		return rect
+	}
+	rect := engine.SyncGetBoundFromAlpha(engine.ToAssetPath(file))
+	cache.put(file, rect)
+	return rect
+}
+
+// -------------------------------------------------------------------------------------
@@ -48,7 +48,7 @@ func (p *quoter) refresh() {
 	center := bound.Center()
 	size := bound.Size
 	extSize := 10.0
-	p.panel.SetText(center, size.Divf(2).Addf(extSize), p.message, p.description)
+	p.panel.SetText(center, size.Divf(2).Addf(extSize), tr(p.message), tr(p.description))
 }
 
 func (p *SpriteImpl) quote_(message, description string) {
@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/goplus/spx/v2/internal/ui"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// rasterizer: a software renderer for the pen layer and screenshots. It doesn't
+// depend on the engine, so it works under pure_engine and gives the same result
+// on every platform, which makes golden-image tests possible.
+//
+// Costumes are sampled by the nearest neighbor, vector costumes and shaders
+// (including graphic effects except GhostEffect) are not supported.
+
+type rasterizer struct {
+	img    *image.RGBA
+	ox, oy float64 // pixel position of the world origin
+	g      *Game
+	images map[string]image.Image
+}
+
+// newRasterizer creates a w x h image, whose center is (cx, cy) of the world.
+func newRasterizer(g *Game, w, h int, cx, cy float64) *rasterizer {
+	return &rasterizer{
+		img:    image.NewRGBA(image.Rect(0, 0, w, h)),
+		ox:     float64(w)/2 - cx,
+		oy:     float64(h)/2 + cy,
+		g:      g,
+		images: make(map[string]image.Image),
+	}
+}
+
+func (p *rasterizer) toPixel(pt mathf.Vec2) mathf.Vec2 {
+	return mathf.NewVec2(pt.X+p.ox, p.oy-pt.Y)
+}
+
+func (p *rasterizer) loadImage(file string) image.Image {
+	if img, ok := p.images[file]; ok {
+		return img
+	}
+	var img image.Image
+	if f, err := p.g.fs.Open(file); err == nil {
+		img, _, err = image.Decode(f)
+		f.Close()
+		if err != nil && debugLoad {
+			log.Println("rasterizer: can't decode", file, err)
+		}
+	}
+	p.images[file] = img
+	return img
+}
+
+// costumeImage returns the image and region of a costume.
+func (p *rasterizer) costumeImage(cs *costume) (image.Image, image.Rectangle) {
+	src := p.loadImage(cs.path)
+	if src == nil {
+		return nil, image.Rectangle{}
+	}
+	region := image.Rect(cs.posX, cs.posY, cs.posX+cs.width, cs.posY+cs.height).Intersect(src.Bounds())
+	if region.Empty() { // image size is unknown under pure_engine
+		region = src.Bounds()
+	}
+	return src, region
+}
+
+// drawImage draws region of src centered at center (in world coordinates). The
+// region is scaled by (sx, sy) first, negative scales mirror it, and then
+// rotated by rot degrees clockwise.
+func (p *rasterizer) drawImage(
+	src image.Image, region image.Rectangle, center mathf.Vec2, rot, sx, sy float64, tint mathf.Color) {
+	if sx == 0 || sy == 0 {
+		return
+	}
+	w, h := float64(region.Dx()), float64(region.Dy())
+	c := p.toPixel(center)
+	sin, cos := math.Sincos(toRadian(rot))
+	hw, hh := math.Abs(w*sx)/2, math.Abs(h*sy)/2
+	ex := hw*math.Abs(cos) + hh*math.Abs(sin)
+	ey := hw*math.Abs(sin) + hh*math.Abs(cos)
+	rect := image.Rect(int(math.Floor(c.X-ex)), int(math.Floor(c.Y-ey)),
+		int(math.Ceil(c.X+ex)), int(math.Ceil(c.Y+ey))).Intersect(p.img.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			dx, dy := float64(x)+0.5-c.X, float64(y)+0.5-c.Y
+			u := (dx*cos+dy*sin)/sx + w/2
+			v := (-dx*sin+dy*cos)/sy + h/2
+			if u < 0 || v < 0 || u >= w || v >= h {
+				continue
+			}
+			r, g, b, a := src.At(region.Min.X+int(u), region.Min.Y+int(v)).RGBA()
+			if a == 0 {
+				continue
+			}
+			fa := float64(a)
+			p.blend(x, y, mathf.NewColor(
+				float64(r)/fa*tint.R, float64(g)/fa*tint.G, float64(b)/fa*tint.B, fa/0xffff*tint.A))
+		}
+	}
+}
+
+// blend draws a pixel of color c over the image.
+func (p *rasterizer) blend(x, y int, c mathf.Color) {
+	a := mathf.Clamp01f(c.A)
+	if a == 0 {
+		return
+	}
+	pix := p.img.Pix[p.img.PixOffset(x, y):]
+	pix[0] = uint8(mathf.Clamp01f(c.R)*a*255 + float64(pix[0])*(1-a) + 0.5)
+	pix[1] = uint8(mathf.Clamp01f(c.G)*a*255 + float64(pix[1])*(1-a) + 0.5)
+	pix[2] = uint8(mathf.Clamp01f(c.B)*a*255 + float64(pix[2])*(1-a) + 0.5)
+	pix[3] = uint8(a*255 + float64(pix[3])*(1-a) + 0.5)
+}
+
+func (p *rasterizer) drawBackdrop(g *Game) {
+	if len(g.costumes) == 0 {
+		return
+	}
+	src, region := p.costumeImage(g.costumes[g.costumeIndex_])
+	if src == nil {
+		return
+	}
+	dstW, dstH := g.getBackdropRenderSize()
+	alpha := 1 - mathf.Clamp01f(g.greffUniforms[GhostEffect]/100)
+	p.drawImage(src, region, mathf.NewVec2(0, 0), 0,
+		dstW/float64(region.Dx()), dstH/float64(region.Dy()), mathf.NewColor(1, 1, 1, alpha))
+}
+
+func (p *rasterizer) drawSprite(sp *SpriteImpl) {
+	cs := sp.renderCostume()
+	src, region := p.costumeImage(cs)
+	if src == nil {
+		return
+	}
+	res := float64(cs.bitmapResolution)
+	x, y := sp.getXY()
+	sx, sy := sp.getScaleXY()
+	ox, oy := renderOffset(sp, float64(region.Dx())/res, float64(region.Dy())/res)
+	rot, hScale := calcRenderRotation(sp)
+	sx, sy = sx/res*hScale, sy/res
+	if sp.flipH {
+		sx = -sx
+	}
+	if sp.flipV {
+		sy = -sy
+	}
+	tint := sp.tint
+	tint.A *= 1 - mathf.Clamp01f(sp.greffUniforms[GhostEffect]/100)
+	p.drawImage(src, region, mathf.NewVec2(x+ox, y+oy), rot, sx, sy, tint)
+}
+
+func (p *rasterizer) drawTilemap(tm *tilemap) {
+	if !tm.visible {
+		return
+	}
+	w, h := float64(p.img.Rect.Dx()), float64(p.img.Rect.Dy())
+	col0 := max(int(math.Floor((-p.ox-tm.x)/tm.tileW)), 0)
+	row0 := max(int(math.Floor((tm.y-p.oy)/tm.tileH)), 0)
+	col1 := min(int(math.Floor((w-p.ox-tm.x)/tm.tileW)), tm.cols-1)
+	row1 := min(int(math.Floor((tm.y-p.oy+h)/tm.tileH)), tm.rows-1)
+	for row := row0; row <= row1; row++ {
+		for col := col0; col <= col1; col++ {
+			i := row*tm.cols + col
+			gid := tm.tiles[i]
+			ts := tm.tilesetOf(gid)
+			if ts == nil {
+				continue
+			}
+			r, ok := ts.region(gid)
+			if !ok {
+				continue
+			}
+			src := p.loadImage(ts.path)
+			if src == nil {
+				continue
+			}
+			region := image.Rect(int(r.Position.X), int(r.Position.Y),
+				int(r.Position.X+r.Size.X), int(r.Position.Y+r.Size.Y)).Intersect(src.Bounds())
+			if region.Empty() {
+				continue
+			}
+			sx, sy := tm.tileW/float64(ts.tileW), tm.tileH/float64(ts.tileH)
+			if tm.flips != nil {
+				if tm.flips[i]&tileFlipH != 0 {
+					sx = -sx
+				}
+				if tm.flips[i]&tileFlipV != 0 {
+					sy = -sy
+				}
+			}
+			x, y := tm.cellCenter(col, row)
+			p.drawImage(src, region, mathf.NewVec2(x, y), 0, sx, sy, mathf.NewColor(1, 1, 1, 1))
+		}
+	}
+}
+
+// drawStage draws the backdrop, tilemap layers, the pen layer, visible sprites
+// and their speech bubbles in order.
+func (p *rasterizer) drawStage(g *Game) {
+	p.drawBackdrop(g)
+	for _, tm := range g.tilemaps {
+		p.drawTilemap(tm)
+	}
+	p.drawPenBaked(g)
+	for _, shape := range g.penShapes {
+		p.drawPenShape(shape)
+	}
+	var sprites []*SpriteImpl
+	for _, item := range g.items {
+		if sp, ok := item.(*SpriteImpl); ok && sp.isVisible && !sp.HasDestroyed {
+			sprites = append(sprites, sp)
+		}
+	}
+	sort.SliceStable(sprites, func(i, j int) bool {
+		return sprites[i].layer < sprites[j].layer
+	})
+	for _, sp := range sprites {
+		p.drawSprite(sp)
+	}
+	for _, sp := range sprites {
+		if sp.sayObj != nil {
+			p.drawSay(sp.sayObj)
+		}
+	}
+}
+
+const (
+	sayPadding     = 10.0 // padding of texts in a speech bubble
+	sayLineSpacing = 1.3  // line height / font size
+	sayTailHeight  = 8.0  // gap between a speech bubble and its sprite
+)
+
+// drawSay draws a speech bubble above its sprite like the engine, texts are
+// in the sprite font (see rasterFont) with styles and inline images.
+func (p *rasterizer) drawSay(say *sayOrThinker) {
+	sp := say.sp
+	bound := sp.Bounds()
+	if bound == nil {
+		return
+	}
+	f := sp.rasterFont()
+	lines := ui.LayoutSay(tr(say.msg), f)
+	w, h := 0.0, 0.0
+	for _, line := range lines {
+		w = max(w, line.Width)
+		h += line.Size * sayLineSpacing
+	}
+	w, h = w+2*sayPadding, h+2*sayPadding
+
+	// the bubble is on the right of the sprite if it's on the left half
+	top := bound.Center()
+	top.Y += bound.Size.Y / 2
+	pt := p.toPixel(top)
+	imgW, imgH := float64(p.img.Rect.Dx()), float64(p.img.Rect.Dy())
+	x0 := pt.X
+	if pt.X > imgW/2 {
+		x0 -= w
+	}
+	x0 = max(0, min(x0, imgW-w))
+	y0 := max(0, min(pt.Y-sayTailHeight-h, imgH-h))
+	p.fillRect(x0, y0, w, h, mathf.NewColor(0.6, 0.6, 0.6, 1))
+	p.fillRect(x0+1, y0+1, w-2, h-2, mathf.NewColor(1, 1, 1, 1))
+
+	y := y0 + sayPadding
+	for _, line := range lines {
+		lineH := line.Size * sayLineSpacing
+		for _, run := range line.Runs {
+			size := run.Size
+			if size == 0 {
+				size = ui.SayFontSize
+			}
+			x := x0 + sayPadding + run.X
+			if run.Image != "" {
+				if src := p.loadImage(run.Image); src != nil {
+					region := src.Bounds()
+					center := mathf.NewVec2(x+size/2-p.ox, p.oy-(y+lineH-size/2))
+					p.drawImage(src, region, center, 0,
+						size/float64(region.Dx()), size/float64(region.Dy()), mathf.NewColor(1, 1, 1, 1))
+				}
+				continue
+			}
+			c := mathf.NewColor(0, 0, 0, 1)
+			if run.HasColor {
+				c = run.Color
+			}
+			src := image.NewUniform(color.NRGBA{
+				uint8(mathf.Clamp01f(c.R) * 255), uint8(mathf.Clamp01f(c.G) * 255),
+				uint8(mathf.Clamp01f(c.B) * 255), uint8(mathf.Clamp01f(c.A) * 255)})
+			ascent, descent := f.Metrics(size)
+			baseline := y + lineH - (lineH-ascent-descent)/2 - descent
+			f.Draw(p.img, src, x, baseline, run.Text, size)
+			if run.Bold {
+				f.Draw(p.img, src, x+1, baseline, run.Text, size)
+			}
+		}
+		y += lineH
+	}
+}
+
+// fillRect fills a rectangle in pixels with color c.
+func (p *rasterizer) fillRect(x, y, w, h float64, c mathf.Color) {
+	rect := image.Rect(int(x), int(y), int(math.Ceil(x+w)), int(math.Ceil(y+h))).Intersect(p.img.Rect)
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for px := rect.Min.X; px < rect.Max.X; px++ {
+			p.blend(px, py, c)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------
@@ -49,7 +49,11 @@ func (p *sayOrThinker) refresh() {
 	bound := p.sp.Bounds()
 	center := bound.Center()
 	size := bound.Size
-	p.panel.SetText(p.sp.g.getWindowSize(), center, size, p.msg)
+	font := ""
+	if p.sp.fontFile != "" {
+		font = engine.ToAssetPath(p.sp.fontFile)
+	}
+	p.panel.SetText(p.sp.g.getWindowSize(), center, size, tr(p.msg), font, p.sp.textMeasurer())
 }
 
 // -------------------------------------------------------------------------------------
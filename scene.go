@@ -0,0 +1,355 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"log"
+	"reflect"
+
+	"github.com/goplus/spx/v2/internal/engine"
+)
+
+// -------------------------------------------------------------------------------------
+// scenes: a scene is scenes/<name>.json in the same format as index.json (only
+// zorder, backdrops, backdropIndex, map, camera, shader, font, tilemaps and bgm
+// are used).
+// LoadScene unloads everything on the stage except persistent sprites (see
+// SetPersistent), and then loads the scene.
+//
+// A scene is only a reload of the stage data by spx, it isn't a scene of the
+// engine (the engine's ChangeSceneToFile isn't used): the engine keeps running
+// the same scene tree, and spx destroys and creates its sprites and UI nodes.
+
+type SceneTransition int
+
+const (
+	TransitionNone SceneTransition = iota
+	TransitionFade
+	TransitionSlideLeft  // the old scene moves out to the left
+	TransitionSlideRight // the old scene moves out to the right
+	TransitionSlideUp    // the old scene moves out to the top
+	TransitionSlideDown  // the old scene moves out to the bottom
+)
+
+const defaultTransitionSecs = 0.5
+
+func sceneFile(name string) string {
+	return "scenes/" + name + ".json"
+}
+
+// SceneName returns the name of the current scene, it's empty if no scene is
+// loaded by LoadScene.
+func (p *Game) SceneName() string {
+	return p.sceneName
+}
+
+func (p *Game) LoadScene__0(name string) {
+	p.loadScene(name, TransitionNone, 0)
+}
+
+func (p *Game) LoadScene__1(name string, transition SceneTransition) {
+	p.loadScene(name, transition, defaultTransitionSecs)
+}
+
+// LoadScene__2 loads a scene with a transition taking secs seconds in total
+// (half for the old scene to go out and half for the new one to come in).
+func (p *Game) LoadScene__2(name string, transition SceneTransition, secs float64) {
+	p.loadScene(name, transition, secs)
+}
+
+func (p *Game) loadScene(name string, transition SceneTransition, secs float64) {
+	if debugInstr {
+		log.Println("LoadScene", name, transition, secs)
+	}
+	if p.isLoadingScene {
+		log.Println("LoadScene: another scene is loading -", name)
+		return
+	}
+	var scene projConfig
+	if err := loadJson(&scene, p.fs, sceneFile(name)); err != nil {
+		log.Println("LoadScene:", err)
+		return
+	}
+	p.isLoadingScene = true
+	defer func() {
+		p.isLoadingScene = false
+	}()
+
+	tr := p.beginTransition(transition, secs/2)
+	unloaded := p.unloadScene()
+	started := p.setupScene(name, &scene)
+	p.sinkMgr.doWhenStartOf(started)
+	p.sinkMgr.doWhenSceneLoaded(name)
+	tr.end(p, secs/2)
+
+	if me := gco.Current(); me != nil && unloaded[me.Obj] {
+		gco.Abort()
+	}
+}
+
+// unloadScene removes all shapes except persistent sprites from the stage,
+// and resets prototypes of non-persistent sprites. It returns the unloaded
+// sprites.
+func (p *Game) unloadScene() map[threadObj]bool {
+	unloaded := make(map[threadObj]bool)
+	var keeps []Shape
+	var sprites []*SpriteImpl
+	var panels []*Monitor
+	var measures []*measure
+	for _, item := range p.items {
+		switch v := item.(type) {
+		case *SpriteImpl:
+			if v.isPersistent {
+				keeps = append(keeps, v)
+				continue
+			}
+			sprites = append(sprites, v)
+			unloaded[v] = true
+		case *Monitor:
+			panels = append(panels, v)
+		case *measure:
+			measures = append(measures, v)
+		}
+	}
+	// remove shapes before destroying them, so that they are not synced again
+	p.items = keeps
+	preloaded := p.preloaded // call Preload again to hold assets of the new scene
+	p.preloaded = nil
+
+	for _, spr := range sprites {
+		spr.doStopSay()
+		spr.doDeleteClone()
+		spr.destroyPen()
+		spr.detachAll()
+		spr.Stop(ThisSprite)
+		spr.HasDestroyed = true
+		if spr.audioId != 0 {
+			p.sounds.releaseAudio(spr.audioId)
+			spr.audioId = 0
+		}
+	}
+	engine.WaitMainThread(func() {
+		for _, spr := range sprites {
+			if spr.syncSprite != nil {
+				spr.syncSprite.Destroy()
+				spr.syncSprite = nil
+			}
+		}
+		for _, m := range panels {
+			m.panel.Destroy()
+		}
+		for _, m := range measures {
+			m.panel.Destroy()
+		}
+		for _, holder := range preloaded {
+			if holder != nil {
+				holder.Destroy()
+			}
+		}
+	})
+
+	p.unloadTilemaps()
+
+	g := reflect.ValueOf(p.gamer_).Elem()
+	for name, sp := range p.sprs {
+		if spr := spriteOf(sp); spr != nil && !spr.isPersistent {
+			if err := p.loadSprite(sp, name, g); err != nil {
+				log.Println("LoadScene:", err)
+			}
+		}
+	}
+	return unloaded
+}
+
+// setupScene loads backdrops and shapes of a scene like loadIndex, and returns
+// the new sprites whose onStart handlers should be called.
+func (p *Game) setupScene(name string, scene *projConfig) map[threadObj]bool {
+	if backdrops := scene.getBackdrops(); len(backdrops) > 0 {
+		p.baseObj.initBackdrops("", backdrops, scene.getBackdropIndex())
+		p.worldWidth_ = scene.Map.Width
+		p.worldHeight_ = scene.Map.Height
+		p.doWorldSize()
+		p.mapMode = toMapMode(scene.Map.Mode)
+		p.baseObj.initShader(scene.Shader)
+		p.applyShader(false)
+		p.setupBackdrop()
+	}
+	p.setFont(scene.Font)
+	if err := p.loadTilemaps(scene.Tilemaps); err != nil {
+		log.Println("LoadScene:", err)
+	}
+
+	g := reflect.ValueOf(p.gamer_).Elem()
+	keeps := p.items
+	p.items = make([]Shape, 0, len(scene.Zorder)+len(keeps))
+	added := make(map[Shape]bool, len(keeps))
+	inits := make([]Sprite, 0, len(scene.Zorder))
+	for _, v := range scene.Zorder {
+		if name, ok := v.(string); ok {
+			sp := p.getSpriteProtoByName(name, g)
+			spr := spriteOf(sp)
+			if spr.isPersistent && p.containsShape(keeps, spr) {
+				if !added[spr] {
+					added[spr] = true
+					p.addShape(spr)
+				}
+				continue
+			}
+			p.addShape(spr)
+			inits = append(inits, sp)
+		} else {
+			inits = p.addSpecialShape(g, v.(specsp), inits)
+		}
+	}
+	for _, item := range keeps {
+		if !added[item] {
+			p.addShape(item)
+		}
+	}
+	p.updateRenderLayers()
+
+	started := make(map[threadObj]bool, len(inits))
+	for _, ini := range inits {
+		spr := spriteOf(ini)
+		if spr != nil {
+			spr.OnStart(func() {
+				spr.awake()
+			})
+			started[spr] = true
+		}
+		runMain(ini.Main)
+	}
+
+	if scene.Camera != nil && scene.Camera.On != "" {
+		p.Camera.On__2(scene.Camera.On)
+	}
+	if scene.Bgm != "" {
+		p.Play__5(scene.Bgm, &PlayOptions{Action: PlayRewind, Loop: true, Wait: false, Music: true})
+	}
+	p.sceneName = name
+	p.manifest = p.buildManifest()
+	return started
+}
+
+func (p *Game) containsShape(items []Shape, shape Shape) bool {
+	for _, item := range items {
+		if item == shape {
+			return true
+		}
+	}
+	return false
+}
+
+// -------------------------------------------------------------------------------------
+
+type sceneTransition struct {
+	kind   SceneTransition
+	ghosts map[*baseObj]float64 // ghost effects before fading out
+	x, y   float64              // camera position before sliding out
+	on     any                  // what the camera followed before sliding out
+}
+
+// stageObjs returns the backdrop and all sprites on the stage.
+func (p *Game) stageObjs() []*baseObj {
+	objs := []*baseObj{&p.baseObj}
+	for _, item := range p.items {
+		if spr, ok := item.(*SpriteImpl); ok {
+			objs = append(objs, &spr.baseObj)
+		}
+	}
+	return objs
+}
+
+func (p *Game) slideOffset(kind SceneTransition) (dx, dy float64) {
+	w, h := float64(p.windowWidth_), float64(p.windowHeight_)
+	switch kind {
+	case TransitionSlideLeft:
+		return w, 0
+	case TransitionSlideRight:
+		return -w, 0
+	case TransitionSlideUp:
+		return 0, -h
+	case TransitionSlideDown:
+		return 0, h
+	}
+	return 0, 0
+}
+
+// beginTransition moves the old scene out.
+func (p *Game) beginTransition(kind SceneTransition, secs float64) *sceneTransition {
+	tr := &sceneTransition{kind: kind}
+	switch kind {
+	case TransitionFade:
+		objs := p.stageObjs()
+		tr.ghosts = make(map[*baseObj]float64, len(objs))
+		for _, obj := range objs {
+			tr.ghosts[obj] = obj.greffUniforms[GhostEffect]
+		}
+		tween(secs, func(t float64) {
+			for _, obj := range objs {
+				from := tr.ghosts[obj]
+				obj.setEffect(GhostEffect, from+(100-from)*t)
+			}
+		})
+	case TransitionSlideLeft, TransitionSlideRight, TransitionSlideUp, TransitionSlideDown:
+		tr.on, p.Camera.on_ = p.Camera.on_, nil
+		x, y := p.Camera.GetXYpos()
+		dx, dy := p.slideOffset(kind)
+		tween(secs, func(t float64) {
+			p.Camera.SetXYpos(x+dx*t, y+dy*t)
+		})
+		tr.x, tr.y = x, y
+	}
+	return tr
+}
+
+// end moves the new scene in.
+func (tr *sceneTransition) end(p *Game, secs float64) {
+	switch tr.kind {
+	case TransitionFade:
+		objs := p.stageObjs()
+		ghosts := make([]float64, len(objs))
+		for i, obj := range objs {
+			to, ok := tr.ghosts[obj] // persistent sprites and the backdrop
+			if !ok {
+				to = obj.greffUniforms[GhostEffect]
+			}
+			ghosts[i] = to
+			obj.setEffect(GhostEffect, 100)
+		}
+		tween(secs, func(t float64) {
+			for i, obj := range objs {
+				obj.setEffect(GhostEffect, 100+(ghosts[i]-100)*t)
+			}
+		})
+	case TransitionSlideLeft, TransitionSlideRight, TransitionSlideUp, TransitionSlideDown:
+		x, y := tr.x, tr.y
+		dx, dy := p.slideOffset(tr.kind)
+		tween(secs, func(t float64) {
+			p.Camera.SetXYpos(x-dx*(1-t), y-dy*(1-t))
+		})
+		// follow the old target again if the new scene doesn't set the camera,
+		// eg. a persistent player sprite
+		if p.Camera.on_ == nil {
+			if spr, ok := tr.on.(*SpriteImpl); !ok || !spr.HasDestroyed {
+				p.Camera.on_ = tr.on
+			}
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------
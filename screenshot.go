@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// -------------------------------------------------------------------------------------
+
+// Screenshot saves what the camera sees as a PNG file, see ScreenshotImage. A
+// relative path is relative to the persistent data directory. OnScreenshot
+// handlers are called with the full path after the file is saved.
+func (p *Game) Screenshot(path string) error {
+	if debugInstr {
+		log.Println("Screenshot", path)
+	}
+	img := p.ScreenshotImage()
+	if img == nil {
+		return errNoScreenshot
+	}
+	return p.saveImage(path, img)
+}
+
+// CapturePen saves the pen layer as a PNG file, see Screenshot.
+func (p *Game) CapturePen(path string) error {
+	if debugInstr {
+		log.Println("CapturePen", path)
+	}
+	return p.saveImage(path, p.PenImage())
+}
+
+func (p *Game) saveImage(path string, img image.Image) (err error) {
+	if !filepath.IsAbs(path) {
+		if dir := platformMgr.GetPersistantDataDir(); dir != "" {
+			path = filepath.Join(dir, path)
+		}
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	err = png.Encode(f, img)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return
+	}
+	p.sinkMgr.doWhenScreenshot(path)
+	return
+}
+
+// -------------------------------------------------------------------------------------
@@ -0,0 +1,34 @@
+//go:build !pure_engine
+// +build !pure_engine
+
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"errors"
+	"image"
+)
+
+var errNoScreenshot = errors.New("Screenshot: capturing the viewport is not supported by the engine yet")
+
+// ScreenshotImage returns what the camera sees. Shaders, effects and UI are
+// rendered by the engine, and the engine can't capture its viewport yet, so
+// it returns nil. The software rasterizer is only used under pure_engine.
+func (p *Game) ScreenshotImage() image.Image {
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build pure_engine
+// +build pure_engine
+
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"errors"
+	"image"
+)
+
+var errNoScreenshot = errors.New("Screenshot: nothing is rendered")
+
+// ScreenshotImage renders what the camera sees in the size of the window by
+// the software rasterizer, so that screenshots can be compared with golden
+// images in CI.
+func (p *Game) ScreenshotImage() image.Image {
+	cx, cy := p.Camera.GetXYpos()
+	r := newRasterizer(p, p.windowWidth_, p.windowHeight_, cx, cy)
+	r.drawStage(p)
+	return r.img
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"log"
+	"maps"
+
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// custom shaders
+//
+//	"shader": {
+//	  "path": "shaders/outline.gdshader",
+//	  "params": {"outline_width": 2, "outline_color": [1, 0, 0, 1]}
+//	}
+//
+// A custom shader replaces the builtin sprite shader, so it should declare the
+// uniforms of graphic effects (see greffNames) if SetEffect is still needed.
+// Param values are numbers or arrays of up to 4 numbers (vec2/vec3/vec4).
+
+type shaderConfig struct {
+	Path   string         `json:"path"` // relative to the assets directory
+	Params map[string]any `json:"params"`
+}
+
+// initShader applies a shader config, it doesn't touch the engine.
+func (p *baseObj) initShader(cfg *shaderConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Path != "" {
+		p.shader = engine.ToAssetPath(cfg.Path)
+	}
+	for name, v := range cfg.Params {
+		val, ok := toShaderParam(v)
+		if !ok {
+			log.Printf("shader %s: invalid value of param [%s]: %v\n", cfg.Path, name, v)
+			continue
+		}
+		p.requireShaderParams()[name] = val
+	}
+}
+
+func toShaderParam(v any) (any, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case []any:
+		if len(v) == 0 || len(v) > 4 {
+			return nil, false
+		}
+		var vec [4]float64
+		for i, item := range v {
+			f, ok := item.(float64)
+			if !ok {
+				return nil, false
+			}
+			vec[i] = f
+		}
+		return mathf.NewVec4(vec[0], vec[1], vec[2], vec[3]), true
+	}
+	return nil, false
+}
+
+func (p *baseObj) requireShaderParams() map[string]any {
+	params := p.shaderParams
+	if params == nil {
+		params = make(map[string]any)
+		p.shaderParams = params
+	}
+	return params
+}
+
+func (p *baseObj) initShaderFrom(src *baseObj) {
+	p.shader = src.shader
+	p.shaderParams = maps.Clone(src.shaderParams)
+}
+
+func (p *baseObj) getShaderPath() string {
+	if p.shader != "" {
+		return p.shader
+	}
+	return shaderPath
+}
+
+// applyShader sends the custom shader and all its params to the engine.
+func (p *baseObj) applyShader(isSync bool) {
+	if isSync {
+		p.syncApplyShader()
+	} else {
+		engine.WaitMainThread(p.syncApplyShader)
+	}
+}
+
+func (p *baseObj) syncApplyShader() {
+	if p.syncSprite == nil {
+		return
+	}
+	if p.shader != "" || p.hasShader {
+		p.syncSprite.SetMaterialShader(p.getShaderPath())
+		p.hasShader = true
+	}
+	for name, val := range p.shaderParams {
+		p.syncSetShaderParam(name, val)
+	}
+}
+
+func (p *baseObj) syncSetShaderParam(name string, val any) {
+	if p.syncSprite == nil {
+		return
+	}
+	if !p.hasShader {
+		p.syncSprite.SetMaterialShader(p.getShaderPath())
+		p.hasShader = true
+	}
+	switch v := val.(type) {
+	case float64:
+		p.syncSprite.SetMaterialParams(name, v)
+	case mathf.Vec4:
+		p.syncSprite.SetMaterialParamsVec(name, v.X, v.Y, v.Z, v.W)
+	case mathf.Color:
+		p.syncSprite.SetMaterialParamsColor(name, v)
+	}
+}
+
+func (p *baseObj) setShader(path string) {
+	if path != "" {
+		path = engine.ToAssetPath(path)
+	}
+	p.shader = path
+	engine.WaitMainThread(func() {
+		if p.syncSprite == nil {
+			return
+		}
+		p.syncApplyShader()
+		p.applyEffects(true)
+	})
+}
+
+func (p *baseObj) setShaderParam(name string, val any) {
+	p.requireShaderParams()[name] = val
+	engine.WaitMainThread(func() {
+		p.syncSetShaderParam(name, val)
+	})
+}
+
+func (p *baseObj) shaderParam(name string) float64 {
+	if v, ok := p.shaderParams[name].(float64); ok {
+		return v
+	}
+	return 0
+}
+
+// tweenShaderParam changes a param in secs. lerp computes the value at t in
+// [0, 1].
+func (p *baseObj) tweenShaderParam(name string, secs float64, lerp func(t float64) any) {
+	tween(secs, func(t float64) {
+		p.setShaderParam(name, lerp(t))
+	})
+}
+
+func (p *baseObj) tweenShaderParamFloat(name string, val, secs float64) {
+	from := p.shaderParam(name)
+	p.tweenShaderParam(name, secs, func(t float64) any {
+		return mathf.Lerpf(from, val, t)
+	})
+}
+
+func (p *baseObj) tweenShaderParamColor(name string, val Color, secs float64) {
+	from := mathf.NewColor(0, 0, 0, 0)
+	if c, ok := p.shaderParams[name].(mathf.Color); ok {
+		from = c
+	}
+	to := toMathfColor(val)
+	p.tweenShaderParam(name, secs, func(t float64) any {
+		return from.Lerp(to, t)
+	})
+}
+
+// -------------------------------------------------------------------------------------
+
+// SetShader replaces the builtin shader of this sprite by a custom shader,
+// path is relative to the assets directory. An empty path restores the
+// builtin shader.
+func (p *SpriteImpl) SetShader(path string) {
+	if debugInstr {
+		log.Println("SetShader", p.name, path)
+	}
+	p.baseObj.setShader(path)
+}
+
+// SetShaderParam__0 sets a float uniform of the shader of this sprite.
+func (p *SpriteImpl) SetShaderParam__0(name string, val float64) {
+	p.baseObj.setShaderParam(name, val)
+}
+
+// SetShaderParam__1 sets a color uniform of the shader of this sprite.
+func (p *SpriteImpl) SetShaderParam__1(name string, val Color) {
+	p.baseObj.setShaderParam(name, toMathfColor(val))
+}
+
+// SetShaderParam__2 sets a vector uniform of the shader of this sprite.
+func (p *SpriteImpl) SetShaderParam__2(name string, x, y, z, w float64) {
+	p.baseObj.setShaderParam(name, mathf.NewVec4(x, y, z, w))
+}
+
+// ShaderParam returns a float uniform set by SetShaderParam or index.json.
+func (p *SpriteImpl) ShaderParam(name string) float64 {
+	return p.baseObj.shaderParam(name)
+}
+
+// TweenShaderParam__0 changes a float uniform to val in secs seconds.
+func (p *SpriteImpl) TweenShaderParam__0(name string, val, secs float64) {
+	if debugInstr {
+		log.Println("TweenShaderParam", p.name, name, val, secs)
+	}
+	p.baseObj.tweenShaderParamFloat(name, val, secs)
+}
+
+// TweenShaderParam__1 changes a color uniform to val in secs seconds.
+func (p *SpriteImpl) TweenShaderParam__1(name string, val Color, secs float64) {
+	if debugInstr {
+		log.Println("TweenShaderParam", p.name, name, val, secs)
+	}
+	p.baseObj.tweenShaderParamColor(name, val, secs)
+}
+
+// -------------------------------------------------------------------------------------
+
+// SetShader replaces the builtin shader of the backdrop by a custom shader.
+func (p *Game) SetShader(path string) {
+	if debugInstr {
+		log.Println("SetShader", path)
+	}
+	p.baseObj.setShader(path)
+}
+
+func (p *Game) SetShaderParam__0(name string, val float64) {
+	p.baseObj.setShaderParam(name, val)
+}
+
+func (p *Game) SetShaderParam__1(name string, val Color) {
+	p.baseObj.setShaderParam(name, toMathfColor(val))
+}
+
+func (p *Game) SetShaderParam__2(name string, x, y, z, w float64) {
+	p.baseObj.setShaderParam(name, mathf.NewVec4(x, y, z, w))
+}
+
+func (p *Game) ShaderParam(name string) float64 {
+	return p.baseObj.shaderParam(name)
+}
+
+func (p *Game) TweenShaderParam__0(name string, val, secs float64) {
+	p.baseObj.tweenShaderParamFloat(name, val, secs)
+}
+
+func (p *Game) TweenShaderParam__1(name string, val Color, secs float64) {
+	p.baseObj.tweenShaderParamColor(name, val, secs)
+}
+
+// -------------------------------------------------------------------------------------
@@ -171,6 +171,11 @@ type baseObj struct {
 	HasDestroyed   bool
 	isCostumeSet   bool
 	isCostumeDirty bool
+	animCostume    *costume // costume of the current animation frame, see renderCostume
+
+	// per-axis ratios of size set by SetSizeXY, only valid if isStretched
+	isStretched        bool
+	stretchX, stretchY float64
 
 	layer        int
 	isLayerDirty bool
@@ -178,6 +183,8 @@ type baseObj struct {
 	// effects
 	greffUniforms map[EffectKind]float64 // graphic effects
 	hasShader     bool
+	shader        string         // custom shader, empty means the builtin one
+	shaderParams  map[string]any // custom uniforms
 }
 
 func (p *baseObj) setLayer(layer int) { // dying: visible but can't be touched
@@ -201,8 +208,10 @@ func (p *baseObj) initWith(base string, sprite *spriteConfig) {
 		initWithCS(p, base, sprite.CostumeSet)
 	} else if sprite.CostumeMPSet != nil {
 		initWithCMPS(p, base, sprite.CostumeMPSet)
+	} else if sprite.CostumeAtlas != nil {
+		initWithAtlas(p, sprite.CostumeAtlas)
 	} else {
-		panic("sprite.init should have one of costumes, costumeSet and costumeMPSet")
+		panic("sprite.init should have one of costumes, costumeSet, costumeMPSet and costumeAtlas")
 	}
 	nx := len(p.costumes)
 	costumeIndex := sprite.getCostumeIndex()
@@ -298,6 +307,8 @@ func (p *baseObj) initWithSize(width, height int) {
 func (p *baseObj) initFrom(src *baseObj) {
 	p.costumes = src.costumes
 	p.hasShader = false
+	p.isStretched, p.stretchX, p.stretchY = src.isStretched, src.stretchX, src.stretchY
+	p.initShaderFrom(src)
 	p.setCustumeIndex(src.costumeIndex_)
 }
 
@@ -364,9 +375,37 @@ func (p *baseObj) getCostumeName() SpriteCostumeName {
 func (p *baseObj) getCostumePath() string {
 	return p.costumes[p.costumeIndex_].path
 }
-func (p *baseObj) getCostumeRenderScale() float64 {
-	return 1.0 / float64(p.costumes[p.costumeIndex_].bitmapResolution) * p.scale
+func (p *baseObj) getCostumeRenderScale() mathf.Vec2 {
+	sx, sy := p.getScaleXY()
+	res := float64(p.costumes[p.costumeIndex_].bitmapResolution)
+	return mathf.NewVec2(sx/res, sy/res)
+}
+
+// getScaleXY returns the size of each axis, see SpriteImpl.SetSizeXY.
+func (p *baseObj) getScaleXY() (float64, float64) {
+	if p.isStretched {
+		return p.scale * p.stretchX, p.scale * p.stretchY
+	}
+	return p.scale, p.scale
 }
+
+// getStretch returns the ratio of each axis to the uniform size.
+func (p *baseObj) getStretch() (float64, float64) {
+	if p.isStretched {
+		return p.stretchX, p.stretchY
+	}
+	return 1, 1
+}
+
+// renderCostume returns the costume being rendered, which is the costume of the
+// current frame if an animation of a costume set is playing.
+func (p *baseObj) renderCostume() *costume {
+	if p.animCostume != nil {
+		return p.animCostume
+	}
+	return p.costumes[p.costumeIndex_]
+}
+
 func (p *baseObj) getCostumeSize() (float64, float64) {
 	x, y := p.costumes[p.costumeIndex_].getSize()
 	return float64(x), float64(y)
@@ -490,7 +529,7 @@ func (p *baseObj) _setMaterialParams(effect string, amount float64) {
 		return
 	}
 	if !p.hasShader {
-		p.syncSprite.SetMaterialShader(shaderPath)
+		p.syncSprite.SetMaterialShader(p.getShaderPath())
 		p.hasShader = true
 	}
 	p.syncSprite.SetMaterialParams(effect, amount)
@@ -501,7 +540,7 @@ func (p *baseObj) _setMaterialParamsVec4(effect string, val mathf.Vec4) {
 		return
 	}
 	if !p.hasShader {
-		p.syncSprite.SetMaterialShader(shaderPath)
+		p.syncSprite.SetMaterialShader(p.getShaderPath())
 		p.hasShader = true
 	}
 
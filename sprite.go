@@ -17,13 +17,14 @@
 package spx
 
 import (
-	"fmt"
 	"log"
 	"maps"
 	"math"
 	"reflect"
+	"strconv"
 
 	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/goplus/spx/v2/internal/text"
 	"github.com/goplus/spx/v2/internal/time"
 	"github.com/goplus/spx/v2/internal/tools"
 	"github.com/realdream-ai/mathf"
@@ -65,8 +66,21 @@ type Sprite interface {
 	IEventSinks
 	Shape
 	Main()
-	Animate(name SpriteAnimationName)
-	Ask(msg any)
+	Animate__0(name SpriteAnimationName)
+	Animate__1(name SpriteAnimationName, options *AnimateOptions)
+	AnimationFrame() int
+	AnimationSpeed() float64
+	AnimParam(name string) bool
+	AnimState() string
+	Ask__0(msg any)
+	Ask__1(msg any, secs float64) bool
+	AskChoice__0(question string, options ...string) int
+	AskChoice__1(question string, secs float64, options ...string) int
+	AskNumber__0(question string, low, high float64) float64
+	AskNumber__1(question string, low, high, secs float64) (float64, bool)
+	Attach__0(child Sprite, x, y float64)
+	Attach__1(child Sprite, options *AttachOptions)
+	AttachParent() Sprite
 	BounceOffEdge()
 	Bounds() *mathf.Rect2
 	ChangeEffect(kind EffectKind, delta float64)
@@ -77,7 +91,9 @@ type Sprite interface {
 	ChangeXpos(dx float64)
 	ChangeXYpos(dx, dy float64)
 	ChangeYpos(dy float64)
+	Children() []Sprite
 	ClearGraphicEffects()
+	ClearPen()
 	CostumeHeight() float64
 	CostumeIndex() int
 	CostumeName() SpriteCostumeName
@@ -85,11 +101,22 @@ type Sprite interface {
 	DeleteThisClone()
 	DeltaTime() float64
 	Destroy()
+	Detach()
 	Die()
 	DistanceTo__0(sprite Sprite) float64
 	DistanceTo__1(sprite SpriteName) float64
 	DistanceTo__2(obj specialObj) float64
 	DistanceTo__3(pos Pos) float64
+	DrawCircle__0(x, y, radius float64)
+	DrawCircle__1(x, y, radius float64, fill bool)
+	DrawLine(x1, y1, x2, y2 float64)
+	DrawPolygon__0(points []float64)
+	DrawPolygon__1(points []float64, fill bool)
+	DrawRect__0(x, y, width, height float64)
+	DrawRect__1(x, y, width, height float64, fill bool)
+	DrawText__0(x, y float64, text string)
+	DrawText__1(x, y float64, text string, size float64)
+	Flipped() (horizontal, vertical bool)
 	Glide__0(x, y float64, secs float64)
 	Glide__1(sprite Sprite, secs float64)
 	Glide__2(sprite SpriteName, secs float64)
@@ -109,46 +136,68 @@ type Sprite interface {
 	Move__1(step int)
 	Name() string
 	NextCostume()
-	OnCloned__0(onCloned func(data any))
-	OnCloned__1(onCloned func())
-	OnMoving__0(onMoving func(mi *MovingInfo))
-	OnMoving__1(onMoving func())
-	OnTouchStart__0(onTouchStart func(Sprite))
-	OnTouchStart__1(onTouchStart func())
-	OnTouchStart__2(sprite SpriteName, onTouchStart func(Sprite))
-	OnTouchStart__3(sprite SpriteName, onTouchStart func())
-	OnTouchStart__4(sprites []SpriteName, onTouchStart func(Sprite))
-	OnTouchStart__5(sprites []SpriteName, onTouchStart func())
-	OnTurning__0(onTurning func(ti *TurningInfo))
-	OnTurning__1(onTurning func())
+	OnAnimationFinished__0(onFinished func(name SpriteAnimationName)) *EventHandle
+	OnAnimationFinished__1(name SpriteAnimationName, onFinished func()) *EventHandle
+	OnAnimationLooped__0(onLooped func(name SpriteAnimationName)) *EventHandle
+	OnAnimationLooped__1(name SpriteAnimationName, onLooped func()) *EventHandle
+	OnCloned__0(onCloned func(data any)) *EventHandle
+	OnCloned__1(onCloned func()) *EventHandle
+	OnMoving__0(onMoving func(mi *MovingInfo)) *EventHandle
+	OnMoving__1(onMoving func()) *EventHandle
+	OnTouchStart__0(onTouchStart func(Sprite)) *EventHandle
+	OnTouchStart__1(onTouchStart func()) *EventHandle
+	OnTouchStart__2(sprite SpriteName, onTouchStart func(Sprite)) *EventHandle
+	OnTouchStart__3(sprite SpriteName, onTouchStart func()) *EventHandle
+	OnTouchStart__4(sprites []SpriteName, onTouchStart func(Sprite)) *EventHandle
+	OnTouchStart__5(sprites []SpriteName, onTouchStart func()) *EventHandle
+	OnTurning__0(onTurning func(ti *TurningInfo)) *EventHandle
+	OnTurning__1(onTurning func()) *EventHandle
+	Opacity() float64
 	Parent() *Game
+	PauseAnimation()
 	PenDown()
 	PenUp()
+	Persistent() bool
 	PrevCostume()
 	Quote__0(message string)
 	Quote__1(message string, secs float64)
 	Quote__2(message, description string)
 	Quote__3(message, description string, secs float64)
+	ResumeAnimation()
 	Say__0(msg any)
 	Say__1(msg any, secs float64)
+	SetAnimParam(name string, val bool)
+	SetAnimationSpeed(speed float64)
 	SetCostume__0(costume SpriteCostumeName)
 	SetCostume__1(index float64)
 	SetCostume__2(index int)
 	SetCostume__3(action switchAction)
 	SetDying()
 	SetEffect(kind EffectKind, val float64)
+	SetFlip(horizontal, vertical bool)
+	SetFont(file string)
 	SetHeading(dir Direction)
+	SetOpacity(opacity float64)
 	SetPenColor__0(color Color)
 	SetPenColor__1(kind PenColorParam, value float64)
 	SetPenSize(size float64)
+	SetPersistent(persistent bool)
 	SetRotationStyle(style RotationStyle)
+	SetShader(path string)
+	SetShaderParam__0(name string, val float64)
+	SetShaderParam__1(name string, val Color)
+	SetShaderParam__2(name string, x, y, z, w float64)
 	SetSize(size float64)
+	SetSizeXY(sx, sy float64)
+	SetTint(color Color)
 	SetXpos(x float64)
 	SetXYpos(x, y float64)
 	SetYpos(y float64)
+	ShaderParam(name string) float64
 	Show()
 	ShowVar(name string)
 	Size() float64
+	SizeXY() (sx, sy float64)
 	Stamp()
 	Step__0(step float64)
 	Step__1(step float64, animation SpriteAnimationName)
@@ -156,6 +205,7 @@ type Sprite interface {
 	Think__0(msg any)
 	Think__1(msg any, secs float64)
 	TimeSinceLevelLoad() float64
+	Tint() Color
 	Touching__0(sprite SpriteName) bool
 	Touching__1(sprite Sprite) bool
 	Touching__2(obj specialObj) bool
@@ -166,6 +216,9 @@ type Sprite interface {
 	TurnTo__1(sprite SpriteName)
 	TurnTo__2(dir Direction)
 	TurnTo__3(obj specialObj)
+	TweenShaderParam__0(name string, val, secs float64)
+	TweenShaderParam__1(name string, val Color, secs float64)
+	TweenSizeXY(sx, sy, secs float64)
 	Visible() bool
 	Xpos() float64
 	Ypos() float64
@@ -202,6 +255,9 @@ type SpriteImpl struct {
 	rotationStyle RotationStyle
 	pivot         mathf.Vec2
 
+	tint         mathf.Color
+	flipH, flipV bool
+
 	sayObj           *sayOrThinker
 	quoteObj         *quoter
 	animations       map[SpriteAnimationName]*aniConfig
@@ -221,6 +277,11 @@ type SpriteImpl struct {
 	isPenDown bool
 	isDying   bool
 
+	isPersistent bool // kept when another scene is loaded, see SetPersistent
+
+	font     *text.Font // see SetFont
+	fontFile string
+
 	hasOnTurning    bool
 	hasOnMoving     bool
 	hasOnCloned     bool
@@ -228,8 +289,17 @@ type SpriteImpl struct {
 	hasOnTouching   bool
 	hasOnTouchEnd   bool
 
-	gamer               reflect.Value
-	curAnimState        *animState
+	hasOnAnimFinished bool
+	hasOnAnimLooped   bool
+
+	gamer        reflect.Value
+	curAnimState *animState
+	animSM       *animStateMachine
+	animSpeed    float64 // speed scale set by SetAnimationSpeed
+	animPaused   bool    // paused by PauseAnimation
+
+	attach              *attachInfo   // parent and local transform, see Attach
+	children            []*SpriteImpl // attached sprites
 	defaultCostumeIndex int
 
 	triggerMask   int64
@@ -246,8 +316,9 @@ type SpriteImpl struct {
 	colliderSize   mathf.Vec2
 	colliderRadius float64
 
-	penObj  *engine.Object
-	audioId engine.Object
+	penObj    *engine.Object
+	penStroke *penShape // recording moves of the pen while it is down
+	audioId   engine.Object
 }
 
 func (p *SpriteImpl) SetDying() { // dying: visible but can't be touched
@@ -276,10 +347,26 @@ func (p *SpriteImpl) init(
 	p.g, p.name, p.sprite = g, name, sprite
 	p.x, p.y = spriteCfg.X, spriteCfg.Y
 	p.scale = spriteCfg.Size
+	p.animSpeed = 1
 	p.direction = spriteCfg.Heading
 	p.rotationStyle = toRotationStyle(spriteCfg.RotationStyle)
 	p.isVisible = spriteCfg.Visible
+	p.isPersistent = spriteCfg.Persistent
+	p.setFont(spriteCfg.Font)
 	p.pivot = spriteCfg.Pivot
+	p.tint = mathf.NewColor(1, 1, 1, 1)
+	if spriteCfg.Tint != "" {
+		tint, err := mathf.NewColorAny(spriteCfg.Tint)
+		if err != nil {
+			log.Panicf("sprite %s: invalid tint %q: %v", name, spriteCfg.Tint, err)
+		}
+		p.tint = tint
+	}
+	p.flipH, p.flipV = spriteCfg.FlipH, spriteCfg.FlipV
+	if spriteCfg.Opacity != nil {
+		p.baseObj.requireGreffUniforms()[GhostEffect] = 100 - mathf.Clamp(*spriteCfg.Opacity, 0, 100)
+	}
+	p.baseObj.initShader(spriteCfg.Shader)
 
 	p.animBindings = make(map[string]string)
 	for key, val := range spriteCfg.AnimBindings {
@@ -306,6 +393,14 @@ func (p *SpriteImpl) init(
 	p.defaultAnimation = spriteCfg.DefaultAnimation
 	p.animations = make(map[string]*aniConfig)
 	anims := spriteCfg.FAnimations
+	if spriteCfg.CostumeAtlas != nil {
+		// animations from frame tags, can be overridden by fAnimations
+		tagAnims := atlasAnimations(spriteCfg.CostumeAtlas)
+		for key, val := range anims {
+			tagAnims[key] = val
+		}
+		anims = tagAnims
+	}
 	for key, val := range anims {
 		var ani = val
 		_, ok := p.animations[key]
@@ -324,7 +419,7 @@ func (p *SpriteImpl) init(
 		from, to := p.getFromAnToForAniFrames(ani.FrameFrom, ani.FrameTo)
 		ani.IFrameFrom, ani.IFrameTo = int(from), int(to)
 		ani.Speed = 1
-		ani.Duration = (math.Abs(float64(ani.IFrameFrom-ani.IFrameTo)) + 1) / float64(ani.FrameFps)
+		ani.Duration = float64(len(ani.frameSeq())) / float64(ani.FrameFps)
 		p.animations[key] = ani
 	}
 
@@ -332,6 +427,9 @@ func (p *SpriteImpl) init(
 	for animName, ani := range p.animations {
 		registerAnimToEngine(p.name, animName, ani, p.baseObj.costumes, p.isCostumeSet)
 	}
+	if spriteCfg.AnimStates != nil {
+		p.animSM = newAnimStateMachine(p, spriteCfg.AnimStates)
+	}
 
 }
 
@@ -346,10 +444,21 @@ func (p *SpriteImpl) InitFrom(src *SpriteImpl) {
 	p.g, p.name = src.g, src.name
 	p.x, p.y = src.x, src.y
 	p.scale = src.scale
+	p.animSpeed = src.animSpeed
+	p.animPaused = src.animPaused
 	p.direction = src.direction
 	p.rotationStyle = src.rotationStyle
+	p.tint = src.tint
+	p.flipH, p.flipV = src.flipH, src.flipV
+	p.isPersistent = src.isPersistent
+	p.font, p.fontFile = src.font, src.fontFile
 	p.sayObj = nil
 	p.animations = src.animations
+	p.animBindings = src.animBindings
+	p.defaultAnimation = src.defaultAnimation
+	if src.animSM != nil {
+		p.animSM = src.animSM.clone(src)
+	}
 	// clone effect params
 	p.greffUniforms = maps.Clone(src.greffUniforms)
 
@@ -364,6 +473,12 @@ func (p *SpriteImpl) InitFrom(src *SpriteImpl) {
 	p.isVisible = src.isVisible
 	p.isCloned_ = true
 	p.isPenDown = src.isPenDown
+	p.penStroke = nil
+	if p.isPenDown && p.penObj != nil {
+		p.beginPenStroke()
+	}
+	p.attach = nil
+	p.children = nil
 	p.isDying = false
 
 	p.hasOnTurning = false
@@ -372,6 +487,8 @@ func (p *SpriteImpl) InitFrom(src *SpriteImpl) {
 	p.hasOnTouchStart = false
 	p.hasOnTouching = false
 	p.hasOnTouchEnd = false
+	p.hasOnAnimFinished = false
+	p.hasOnAnimLooped = false
 
 	p.collisionMask = src.collisionMask
 	p.collisionLayer = src.collisionLayer
@@ -479,22 +596,21 @@ func Gopt_SpriteImpl_Clone__1(sprite Sprite, data any) {
 	}
 }
 
-func (p *SpriteImpl) OnCloned__0(onCloned func(data any)) {
+func (p *SpriteImpl) OnCloned__0(onCloned func(data any)) *EventHandle {
 	p.syncSprite = nil
 	p.hasOnCloned = true
-	p.allWhenCloned = &eventSink{
-		prev:  p.allWhenCloned,
+	return p.addSink(&p.allWhenCloned, &eventSink{
 		pthis: p,
 		sink:  onCloned,
 		cond: func(data any) bool {
 			return data == p
 		},
-	}
+	})
 }
 
-func (p *SpriteImpl) OnCloned__1(onCloned func()) {
+func (p *SpriteImpl) OnCloned__1(onCloned func()) *EventHandle {
 	p.syncSprite = nil
-	p.OnCloned__0(func(any) {
+	return p.OnCloned__0(func(any) {
 		onCloned()
 	})
 }
@@ -517,26 +633,25 @@ func (p *SpriteImpl) fireTouchEnd(obj *SpriteImpl) {
 	}
 }
 
-func (p *SpriteImpl) OnTouchStart__0(onTouchStart func(Sprite)) {
+func (p *SpriteImpl) OnTouchStart__0(onTouchStart func(Sprite)) *EventHandle {
 	p.hasOnTouchStart = true
-	p.allWhenTouchStart = &eventSink{
-		prev:  p.allWhenTouchStart,
+	return p.addSink(&p.allWhenTouchStart, &eventSink{
 		pthis: p,
 		sink:  onTouchStart,
 		cond: func(data any) bool {
 			return data == p
 		},
-	}
+	})
 }
 
-func (p *SpriteImpl) OnTouchStart__1(onTouchStart func()) {
-	p.OnTouchStart__0(func(Sprite) {
+func (p *SpriteImpl) OnTouchStart__1(onTouchStart func()) *EventHandle {
+	return p.OnTouchStart__0(func(Sprite) {
 		onTouchStart()
 	})
 }
 
-func (p *SpriteImpl) OnTouchStart__2(sprite SpriteName, onTouchStart func(Sprite)) {
-	p.OnTouchStart__0(func(s Sprite) {
+func (p *SpriteImpl) OnTouchStart__2(sprite SpriteName, onTouchStart func(Sprite)) *EventHandle {
+	return p.OnTouchStart__0(func(s Sprite) {
 		impl := spriteOf(s)
 		if impl != nil && impl.name == sprite {
 			onTouchStart(s)
@@ -544,14 +659,14 @@ func (p *SpriteImpl) OnTouchStart__2(sprite SpriteName, onTouchStart func(Sprite
 	})
 }
 
-func (p *SpriteImpl) OnTouchStart__3(sprite SpriteName, onTouchStart func()) {
-	p.OnTouchStart__2(sprite, func(Sprite) {
+func (p *SpriteImpl) OnTouchStart__3(sprite SpriteName, onTouchStart func()) *EventHandle {
+	return p.OnTouchStart__2(sprite, func(Sprite) {
 		onTouchStart()
 	})
 }
 
-func (p *SpriteImpl) OnTouchStart__4(sprites []SpriteName, onTouchStart func(Sprite)) {
-	p.OnTouchStart__0(func(s Sprite) {
+func (p *SpriteImpl) OnTouchStart__4(sprites []SpriteName, onTouchStart func(Sprite)) *EventHandle {
+	return p.OnTouchStart__0(func(s Sprite) {
 		impl := spriteOf(s)
 		if impl != nil {
 			for _, spName := range sprites {
@@ -564,8 +679,8 @@ func (p *SpriteImpl) OnTouchStart__4(sprites []SpriteName, onTouchStart func(Spr
 	})
 }
 
-func (p *SpriteImpl) OnTouchStart__5(sprites []SpriteName, onTouchStart func()) {
-	p.OnTouchStart__4(sprites, func(Sprite) {
+func (p *SpriteImpl) OnTouchStart__5(sprites []SpriteName, onTouchStart func()) *EventHandle {
+	return p.OnTouchStart__4(sprites, func(Sprite) {
 		onTouchStart()
 	})
 }
@@ -587,20 +702,19 @@ func (p *MovingInfo) Dy() float64 {
 	return p.NewY - p.OldY
 }
 
-func (p *SpriteImpl) OnMoving__0(onMoving func(mi *MovingInfo)) {
+func (p *SpriteImpl) OnMoving__0(onMoving func(mi *MovingInfo)) *EventHandle {
 	p.hasOnMoving = true
-	p.allWhenMoving = &eventSink{
-		prev:  p.allWhenMoving,
+	return p.addSink(&p.allWhenMoving, &eventSink{
 		pthis: p,
 		sink:  onMoving,
 		cond: func(data any) bool {
 			return data == p
 		},
-	}
+	})
 }
 
-func (p *SpriteImpl) OnMoving__1(onMoving func()) {
-	p.OnMoving__0(func(mi *MovingInfo) {
+func (p *SpriteImpl) OnMoving__1(onMoving func()) *EventHandle {
+	return p.OnMoving__0(func(mi *MovingInfo) {
 		onMoving()
 	})
 }
@@ -615,20 +729,19 @@ func (p *TurningInfo) Dir() float64 {
 	return p.NewDir - p.OldDir
 }
 
-func (p *SpriteImpl) OnTurning__0(onTurning func(ti *TurningInfo)) {
+func (p *SpriteImpl) OnTurning__0(onTurning func(ti *TurningInfo)) *EventHandle {
 	p.hasOnTurning = true
-	p.allWhenTurning = &eventSink{
-		prev:  p.allWhenTurning,
+	return p.addSink(&p.allWhenTurning, &eventSink{
 		pthis: p,
 		sink:  onTurning,
 		cond: func(data any) bool {
 			return data == p
 		},
-	}
+	})
 }
 
-func (p *SpriteImpl) OnTurning__1(onTurning func()) {
-	p.OnTurning__0(func(*TurningInfo) {
+func (p *SpriteImpl) OnTurning__1(onTurning func()) *EventHandle {
+	return p.OnTurning__0(func(*TurningInfo) {
 		onTurning()
 	})
 }
@@ -653,6 +766,7 @@ func (p *SpriteImpl) Destroy() { // destroy sprite, whether prototype or cloned
 	p.Hide()
 	p.doDeleteClone()
 	p.destroyPen()
+	p.detachAll()
 	p.g.removeShape(p)
 	p.Stop(ThisSprite)
 	if p == gco.Current().Obj {
@@ -700,6 +814,25 @@ func (p *SpriteImpl) IsCloned() bool {
 	return p.isCloned_
 }
 
+// SetPersistent sets whether the sprite is kept when another scene is loaded
+// by LoadScene. Clones of a persistent sprite are persistent too.
+func (p *SpriteImpl) SetPersistent(persistent bool) {
+	if debugInstr {
+		log.Println("SetPersistent", p.name, persistent)
+	}
+	p.isPersistent = persistent
+	if persistent && p.syncSprite != nil {
+		engine.WaitMainThread(func() {
+			engine.SyncSetDontDestroyOnLoad(p.syncSprite.GetId())
+		})
+	}
+}
+
+// Persistent reports whether the sprite is kept when another scene is loaded.
+func (p *SpriteImpl) Persistent() bool {
+	return p.isPersistent
+}
+
 // -----------------------------------------------------------------------------
 
 func (p *SpriteImpl) CostumeName() SpriteCostumeName {
@@ -809,16 +942,20 @@ func (p *SpriteImpl) hasAnim(animName string) bool {
 }
 
 type animState struct {
-	AniType  aniTypeEnum
-	Name     string
-	Duration float64
-	From     any
-	To       any
-	Speed    float64
-	IsLoop   bool
+	AniType   aniTypeEnum
+	Name      string
+	Duration  float64
+	From      any
+	To        any
+	Speed     float64
+	IsLoop    bool
+	IsReverse bool
+	IsDone    bool
 
 	OnStart      *actionConfig
 	OnPlay       *actionConfig
+	OnEnd        *actionConfig
+	Events       map[string]*actionConfig
 	IsCanceled   bool
 	IsKeepOnStop bool
 }
@@ -835,8 +972,11 @@ func (p *SpriteImpl) goAnimateInternal(name SpriteAnimationName, ani *aniConfig,
 		To:           ani.To,
 		Speed:        ani.Speed,
 		IsLoop:       ani.IsLoop,
+		IsReverse:    ani.IsReverse,
 		OnStart:      ani.OnStart,
 		OnPlay:       ani.OnPlay,
+		OnEnd:        ani.OnEnd,
+		Events:       ani.Events,
 		IsKeepOnStop: ani.IsKeepOnStop,
 		IsCanceled:   false,
 	}
@@ -867,10 +1007,11 @@ func doAnimation(p *SpriteImpl, info *animState) {
 		if !p.hasAnim(animName) {
 			return
 		}
-		p.syncSprite.PlayAnim(animName, info.Speed, info.IsLoop, false)
+		p.syncSprite.PlayAnim(animName, info.Speed, info.IsLoop, info.IsReverse)
+		p.syncSetAnimFrame(animName, -1)
 	})
-	if info.OnStart != nil && info.OnStart.Play != "" {
-		p.Play__3(info.OnStart.Play)
+	if info.OnStart != nil {
+		p.doAnimAction(info.OnStart)
 	}
 	if info.AniType == aniTypeFrame {
 		for spriteMgr.IsPlayingAnim(p.syncSprite.GetId()) {
@@ -911,7 +1052,14 @@ func doAnimation(p *SpriteImpl, info *animState) {
 			engine.WaitNextFrame()
 		}
 	}
+	info.IsDone = true
 	if !info.IsCanceled {
+		if info.OnEnd != nil {
+			p.doAnimAction(info.OnEnd)
+		}
+		if p.hasOnAnimFinished {
+			p.doWhenAnimFinished(p, animName)
+		}
 		isNeedPlayDefault := false
 		if animName != p.defaultAnimation && p.isVisible && !info.IsKeepOnStop {
 			dieAnimName := p.getStateAnimName(StateDie)
@@ -925,7 +1073,111 @@ func doAnimation(p *SpriteImpl, info *animState) {
 	}
 }
 
-func (p *SpriteImpl) Animate(name SpriteAnimationName) {
+// doAnimAction runs an action of animation, see actionConfig.
+func (p *SpriteImpl) doAnimAction(act *actionConfig) {
+	if act.Broadcast != "" {
+		p.g.doBroadcast(act.Broadcast, nil, false)
+	}
+	if act.Play != "" {
+		p.Play__3(act.Play)
+	}
+}
+
+// syncSetAnimFrame renders the sprite by the costume of a frame of animation
+// name, -1 means the first frame to play. Frames of a costume set (eg. trimmed
+// frames of an atlas) can have different centers, the engine only plays their
+// regions so the sprite is moved by the center of the current frame.
+func (p *SpriteImpl) syncSetAnimFrame(name string, frame int) {
+	p.animCostume = nil
+	ani, ok := p.animations[name]
+	if !ok || !p.isCostumeSet {
+		return
+	}
+	seq := ani.frameSeq()
+	if frame < 0 {
+		frame = 0
+		if p.curAnimState != nil && p.curAnimState.IsReverse {
+			frame = len(seq) - 1
+		}
+	}
+	if frame < len(seq) {
+		p.animCostume = p.costumes[seq[frame]]
+	}
+	p.updateProxyTransform(true)
+}
+
+// syncOnAnimEvent handles an animation event from engine, it's called in main thread.
+func (p *SpriteImpl) syncOnAnimEvent(ev *engine.AnimEvent) {
+	info := p.curAnimState
+	if info == nil || info.IsCanceled {
+		return
+	}
+	switch ev.Type {
+	case engine.AnimEventFrameChanged:
+		p.syncSetAnimFrame(info.Name, int(ev.Frame))
+		if act, ok := info.Events[strconv.FormatInt(ev.Frame, 10)]; ok {
+			engine.Go(p.pthis, func() {
+				p.doAnimAction(act)
+			})
+		}
+	case engine.AnimEventLooped:
+		if p.hasOnAnimLooped {
+			p.doWhenAnimLooped(p, info.Name)
+		}
+	}
+}
+
+// OnAnimationFinished is called when an animation of this sprite plays to the end.
+// It isn't called for canceled or looping animations.
+func (p *SpriteImpl) OnAnimationFinished__0(onFinished func(name SpriteAnimationName)) *EventHandle {
+	p.hasOnAnimFinished = true
+	return p.addSink(&p.allWhenAnimFinished, &eventSink{
+		pthis: p,
+		sink:  onFinished,
+		cond: func(data any) bool {
+			return data == p
+		},
+	})
+}
+
+func (p *SpriteImpl) OnAnimationFinished__1(name SpriteAnimationName, onFinished func()) *EventHandle {
+	return p.OnAnimationFinished__0(func(ani SpriteAnimationName) {
+		if ani == name {
+			onFinished()
+		}
+	})
+}
+
+// OnAnimationLooped is called each time a looping animation of this sprite
+// starts over.
+func (p *SpriteImpl) OnAnimationLooped__0(onLooped func(name SpriteAnimationName)) *EventHandle {
+	p.hasOnAnimLooped = true
+	return p.addSink(&p.allWhenAnimLooped, &eventSink{
+		pthis: p,
+		sink:  onLooped,
+		cond: func(data any) bool {
+			return data == p
+		},
+	})
+}
+
+func (p *SpriteImpl) OnAnimationLooped__1(name SpriteAnimationName, onLooped func()) *EventHandle {
+	return p.OnAnimationLooped__0(func(ani SpriteAnimationName) {
+		if ani == name {
+			onLooped()
+		}
+	})
+}
+
+// AnimateOptions are options of Animate.
+type AnimateOptions struct {
+	Wait    bool    // wait until the animation finishes, ignored for looping animations
+	Loop    bool    // play the animation repeatedly
+	Speed   float64 // playback speed, 0 means the default speed
+	Reverse bool    // play frames backwards
+}
+
+func (p *SpriteImpl) Animate__0(name SpriteAnimationName) {
 	if debugInstr {
 		log.Println("==> Animation", name)
 	}
@@ -936,24 +1188,114 @@ func (p *SpriteImpl) Animate(name SpriteAnimationName) {
 	}
 }
 
-// -----------------------------------------------------------------------------
-
-func (p *SpriteImpl) Ask(msg any) {
+// Animate__1 plays an animation with options. Unless options.Wait is set, it
+// returns immediately, so scripts can keep moving the sprite while a walk
+// cycle is looping.
+func (p *SpriteImpl) Animate__1(name SpriteAnimationName, options *AnimateOptions) {
+	if options == nil {
+		p.Animate__0(name)
+		return
+	}
 	if debugInstr {
-		log.Println("Ask", p.name, msg)
+		log.Println("==> Animation", name, *options)
 	}
-	msgStr, ok := msg.(string)
+	ani, ok := p.animations[name]
 	if !ok {
-		msgStr = fmt.Sprint(msg)
+		log.Println("Animation not found:", name)
+		return
+	}
+	anicopy := *ani
+	anicopy.IsLoop = options.Loop
+	anicopy.IsReverse = options.Reverse
+	if options.Speed > 0 {
+		anicopy.Speed = options.Speed
+	}
+	p.goAnimateInternal(name, &anicopy, options.Wait && !options.Loop)
+}
+
+// PauseAnimation pauses the animation of this sprite at the current frame.
+func (p *SpriteImpl) PauseAnimation() {
+	p.animPaused = true
+	p.applyAnimSpeed()
+}
+
+// ResumeAnimation resumes the animation paused by PauseAnimation.
+func (p *SpriteImpl) ResumeAnimation() {
+	p.animPaused = false
+	p.applyAnimSpeed()
+}
+
+// SetAnimationSpeed sets the playback speed scale of animations, 1 is the normal speed.
+func (p *SpriteImpl) SetAnimationSpeed(speed float64) {
+	p.animSpeed = speed
+	p.applyAnimSpeed()
+}
+
+// AnimationSpeed returns the playback speed scale set by SetAnimationSpeed.
+func (p *SpriteImpl) AnimationSpeed() float64 {
+	return p.animSpeed
+}
+
+// AnimationFrame returns the current frame index of the playing animation.
+func (p *SpriteImpl) AnimationFrame() int {
+	if p.syncSprite == nil {
+		return 0
+	}
+	return int(spriteMgr.GetAnimFrame(p.syncSprite.GetId()))
+}
+
+func (p *SpriteImpl) getAnimSpeedScale() float64 {
+	if p.animPaused || p.g.isPaused {
+		return 0
 	}
-	if msgStr == "" {
-		println("ask: msg should not be empty")
+	return p.animSpeed
+}
+
+func (p *SpriteImpl) applyAnimSpeed() {
+	if p.syncSprite == nil {
 		return
 	}
-	p.Say__0(msgStr)
-	p.g.ask(true, msgStr, func(answer string) {
-		p.doStopSay()
-	})
+	spriteMgr.SetAnimSpeedScale(p.syncSprite.GetId(), p.getAnimSpeedScale())
+}
+
+// -----------------------------------------------------------------------------
+
+func (p *SpriteImpl) Ask__0(msg any) {
+	p.Ask__1(msg, 0)
+}
+
+// Ask__1 asks a question by the sprite, see Game.Ask__1.
+func (p *SpriteImpl) Ask__1(msg any, secs float64) bool {
+	if debugInstr {
+		log.Println("Ask", p.name, msg, secs)
+	}
+	return p.g.askText(p, msg, secs)
+}
+
+func (p *SpriteImpl) AskChoice__0(question string, options ...string) int {
+	return p.AskChoice__1(question, 0, options...)
+}
+
+// AskChoice__1 asks a question with options by the sprite, see
+// Game.AskChoice__1.
+func (p *SpriteImpl) AskChoice__1(question string, secs float64, options ...string) int {
+	if debugInstr {
+		log.Println("AskChoice", p.name, question, options, secs)
+	}
+	return p.g.askChoice(p, question, secs, options)
+}
+
+func (p *SpriteImpl) AskNumber__0(question string, low, high float64) float64 {
+	v, _ := p.AskNumber__1(question, low, high, 0)
+	return v
+}
+
+// AskNumber__1 asks for a number by the sprite, see Game.AskNumber__1.
+func (p *SpriteImpl) AskNumber__1(question string, low, high, secs float64) (float64, bool) {
+	if debugInstr {
+		log.Println("AskNumber", p.name, question, low, high, secs)
+	}
+	return p.g.askNumber(p, question, low, high, secs)
 }
 
 func (p *SpriteImpl) Say__0(msg any) {
@@ -1063,6 +1405,7 @@ func (p *SpriteImpl) doMoveToForAnim(x, y float64) {
 }
 func (p *SpriteImpl) updateTransform() {
 	p.updateProxyTransform(false)
+	p.updateChildren()
 }
 
 func (p *SpriteImpl) goMoveForward(step float64) {
@@ -1108,6 +1451,10 @@ func (p *SpriteImpl) Step__2(step int) {
 }
 
 func (p *SpriteImpl) playDefaultAnim() {
+	if p.animSM != nil {
+		p.animSM.reset() // the state machine chooses the animation in next frame
+		return
+	}
 	animName := p.defaultAnimation
 	if p.isVisible {
 		isPlayAnim := false
@@ -1419,6 +1766,105 @@ func (p *SpriteImpl) ChangeSize(delta float64) {
 	p.updateTransform()
 }
 
+// SetSizeXY sets the size of each axis, which is useful for squash-and-stretch
+// effects. Size() returns the larger one, and SetSize/ChangeSize keep the
+// ratio between axes. Negative sizes are not supported, use SetFlip instead.
+func (p *SpriteImpl) SetSizeXY(sx, sy float64) {
+	if debugInstr {
+		log.Println("SetSizeXY", p.name, sx, sy)
+	}
+	p.doSetSizeXY(sx, sy)
+}
+
+// SizeXY returns the size of each axis.
+func (p *SpriteImpl) SizeXY() (sx, sy float64) {
+	return p.getScaleXY()
+}
+
+// TweenSizeXY changes the size of each axis to (sx, sy) in secs seconds.
+func (p *SpriteImpl) TweenSizeXY(sx, sy, secs float64) {
+	if debugInstr {
+		log.Println("TweenSizeXY", p.name, sx, sy, secs)
+	}
+	fromX, fromY := p.getScaleXY()
+	tween(secs, func(t float64) {
+		p.doSetSizeXY(mathf.Lerpf(fromX, sx, t), mathf.Lerpf(fromY, sy, t))
+	})
+}
+
+func (p *SpriteImpl) doSetSizeXY(sx, sy float64) {
+	sx, sy = math.Max(sx, 0), math.Max(sy, 0)
+	scale := math.Max(sx, sy)
+	wasStretched := p.isStretched
+	p.isStretched = sx != sy
+	if p.isStretched {
+		p.stretchX, p.stretchY = sx/scale, sy/scale
+	}
+	p.scale = scale
+	p.isCostumeDirty = true
+	p.updateTransform()
+	if p.isStretched || wasStretched {
+		engine.WaitMainThread(func() {
+			if p.syncSprite != nil {
+				syncApplyPhysicShapes(p, p.syncSprite)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// SetTint multiplies the colors of this sprite by color. White means no tint.
+func (p *SpriteImpl) SetTint(color Color) {
+	if debugInstr {
+		log.Println("SetTint", p.name, color)
+	}
+	p.tint = toMathfColor(color)
+	engine.WaitMainThread(p.syncApplyTint)
+}
+
+func (p *SpriteImpl) Tint() Color {
+	return toSpxColor(p.tint)
+}
+
+// SetFlip mirrors the costume of this sprite. It is independent of the
+// rotation style, so a LeftRight sprite facing left with a horizontal flip
+// shows its original costume.
+func (p *SpriteImpl) SetFlip(horizontal, vertical bool) {
+	if debugInstr {
+		log.Println("SetFlip", p.name, horizontal, vertical)
+	}
+	p.flipH, p.flipV = horizontal, vertical
+	engine.WaitMainThread(p.syncApplyFlip)
+}
+
+func (p *SpriteImpl) Flipped() (horizontal, vertical bool) {
+	return p.flipH, p.flipV
+}
+
+// SetOpacity sets the opacity of this sprite in range [0, 100]. It is the
+// same as SetEffect(GhostEffect, 100-opacity).
+func (p *SpriteImpl) SetOpacity(opacity float64) {
+	p.baseObj.setEffect(GhostEffect, 100-mathf.Clamp(opacity, 0, 100))
+}
+
+func (p *SpriteImpl) Opacity() float64 {
+	return 100 - mathf.Clamp(p.greffUniforms[GhostEffect], 0, 100)
+}
+
+func (p *SpriteImpl) syncApplyTint() {
+	if p.syncSprite != nil {
+		p.syncSprite.SetColor(p.tint)
+	}
+}
+
+func (p *SpriteImpl) syncApplyFlip() {
+	if p.syncSprite != nil {
+		p.syncSprite.SetAnimFlipH(p.flipH)
+		p.syncSprite.SetAnimFlipV(p.flipV)
+	}
+}
+
 // -----------------------------------------------------------------------------
 func (p *SpriteImpl) SetEffect(kind EffectKind, val float64) {
 	p.baseObj.setEffect(kind, val)
@@ -1598,20 +2044,31 @@ const (
 func (p *SpriteImpl) PenUp() {
 	p.checkOrCreatePen()
 	p.isPenDown = false
+	p.penStroke = nil
 	extMgr.PenUp(*p.penObj)
 }
 
 func (p *SpriteImpl) PenDown() {
 	p.checkOrCreatePen()
 	p.isPenDown = true
+	p.penStroke = nil
 	p.movePen(p.x, p.y)
 	extMgr.PenDown(*p.penObj, false)
+	p.beginPenStroke()
 }
 
 func (p *SpriteImpl) Stamp() {
 	p.checkOrCreatePen()
 	extMgr.SetPenStampTexture(*p.penObj, p.getCostumePath())
 	extMgr.PenStamp(*p.penObj)
+	x, y := p.penPos(p.x, p.y)
+	rot, hScale := calcRenderRotation(p)
+	scale := p.getCostumeRenderScale()
+	scale.X *= hScale
+	p.addPenShape(&penShape{
+		points: []mathf.Vec2{mathf.NewVec2(x, y)},
+		stamp:  p.costumes[p.costumeIndex_], scale: scale, rot: rot,
+	})
 }
 
 func (p *SpriteImpl) SetPenColor__0(color Color) {
@@ -1690,6 +2147,7 @@ func (p *SpriteImpl) SetPenSize(size float64) {
 	p.checkOrCreatePen()
 	p.penWidth = size
 	extMgr.SetPenSizeTo(*p.penObj, size)
+	p.restartPenStroke()
 }
 
 func (p *SpriteImpl) ChangePenSize(delta float64) {
@@ -1709,6 +2167,7 @@ func (p *SpriteImpl) destroyPen() {
 	if p.penObj != nil {
 		extMgr.DestroyPen(*p.penObj)
 		p.penObj = nil
+		p.removePenShapes()
 	}
 }
 
@@ -1716,8 +2175,12 @@ func (p *SpriteImpl) movePen(x, y float64) {
 	if p.penObj == nil {
 		return
 	}
-	applyRenderOffset(p, &x, &y)
+	x, y = p.penPos(x, y)
 	extMgr.MovePenTo(*p.penObj, mathf.NewVec2(x, -y))
+	if p.penStroke != nil {
+		p.penStroke.points = append(p.penStroke.points, mathf.NewVec2(x, y))
+		p.g.addPenPoints(1)
+	}
 }
 
 func (p *SpriteImpl) applyPenColorProperty() {
@@ -1728,6 +2191,7 @@ func (p *SpriteImpl) applyPenColorProperty() {
 	p.penBrightness = v * 100
 	p.penTransparency = p.penColor.A * 100
 	extMgr.SetPenColorTo(*p.penObj, p.penColor)
+	p.restartPenStroke()
 }
 
 func (p *SpriteImpl) applyPenHsvProperty() {
@@ -1735,6 +2199,7 @@ func (p *SpriteImpl) applyPenHsvProperty() {
 	p.penColor = color
 	p.penColor.A = p.penTransparency / 100
 	extMgr.SetPenColorTo(*p.penObj, p.penColor)
+	p.restartPenStroke()
 }
 
 // -----------------------------------------------------------------------------
@@ -1774,14 +2239,16 @@ func (p *SpriteImpl) Bounds() *mathf.Rect2 {
 	applyRenderOffset(p, &x, &y)
 
 	if p.triggerType != physicColliderNone {
-		x += p.triggerCenter.X
-		y += p.triggerCenter.Y
-		w = p.triggerSize.X
-		h = p.triggerSize.Y
+		sx, sy := p.getStretch()
+		x += p.triggerCenter.X * sx
+		y += p.triggerCenter.Y * sy
+		w = p.triggerSize.X * sx
+		h = p.triggerSize.Y * sy
 	} else {
 		// calc scale
+		sx, sy := p.getScaleXY()
 		wi, hi := c.getSize()
-		w, h = float64(wi)*p.scale, float64(hi)*p.scale
+		w, h = float64(wi)*sx, float64(hi)*sy
 	}
 
 	rect := mathf.NewRect2(x-w*0.5, y-h*0.5, w, h)
@@ -1817,6 +2284,7 @@ func (p *SpriteImpl) fixWorldRange(x, y float64) (float64, float64) {
 
 // ------------------------ Extra events ----------------------------------------
 func (pself *SpriteImpl) onUpdate(delta float64) {
+	pself.updateAnimStates(delta)
 	if pself.quoteObj != nil {
 		pself.quoteObj.refresh()
 	}
@@ -0,0 +1,679 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"sort"
+
+	spxfs "github.com/goplus/spx/v2/fs"
+	"github.com/goplus/spx/v2/internal/engine"
+	"github.com/realdream-ai/mathf"
+)
+
+// -------------------------------------------------------------------------------------
+// tilemaps: a tilemap layer is a grid of tiles cut from tileset images. Tiles
+// are numbered like Tiled: 0 means empty, and tile n of a tileset is
+// firstGid+n (firstGid of a tileset in index.json is 1). Only tiles in the
+// view are rendered, and each run of solid tiles in a row is a collider.
+
+type tilesetConfig struct {
+	Path       string `json:"path"`
+	TileWidth  int    `json:"tileWidth"`
+	TileHeight int    `json:"tileHeight"`
+	Columns    int    `json:"columns"`
+	Margin     int    `json:"margin"`
+	Spacing    int    `json:"spacing"`
+	Solid      []int  `json:"solid"` // solid tiles, numbered the same as data
+}
+
+type tilemapConfig struct {
+	Name           string         `json:"name"`
+	Path           string         `json:"path"` // Tiled map file (.tmj), each tile layer of it is a tilemap layer
+	Tileset        *tilesetConfig `json:"tileset"`
+	TileWidth      int            `json:"tileWidth"` // default is the tile size of the tileset
+	TileHeight     int            `json:"tileHeight"`
+	Width          int            `json:"width"`  // number of columns
+	Height         int            `json:"height"` // number of rows
+	Data           []int          `json:"data"`   // tiles row by row
+	X              float64        `json:"x"`      // offset from the top-left corner of the world, y is downward
+	Y              float64        `json:"y"`
+	Visible        *bool          `json:"visible"`
+	CollisionLayer *int64         `json:"collisionLayer"`
+	CollisionMask  *int64         `json:"collisionMask"`
+}
+
+type tileset struct {
+	path     string // image path
+	firstGid int
+	tileW    int
+	tileH    int
+	columns  int
+	margin   int
+	spacing  int
+	count    int          // number of tiles, 0 if it's unknown
+	solid    map[int]bool // by tile number
+}
+
+// has reports whether tile gid is in the tileset, tiles are not checked
+// against count if it's unknown.
+func (p *tileset) has(gid int) bool {
+	return gid >= p.firstGid && (p.count == 0 || gid < p.firstGid+p.count)
+}
+
+// region returns the region of tile gid in the tileset image.
+func (p *tileset) region(gid int) (mathf.Rect2, bool) {
+	if !p.has(gid) {
+		return mathf.Rect2{}, false
+	}
+	i := gid - p.firstGid
+	col, row := i%p.columns, i/p.columns
+	return mathf.NewRect2(
+		float64(p.margin+col*(p.tileW+p.spacing)), float64(p.margin+row*(p.tileH+p.spacing)),
+		float64(p.tileW), float64(p.tileH)), true
+}
+
+// countBy returns the number of tiles in an image of imageSize.
+func (p *tileset) countBy(imageSize mathf.Vec2) int {
+	rows := (int(imageSize.Y) - 2*p.margin + p.spacing) / (p.tileH + p.spacing)
+	return max(rows, 0) * p.columns
+}
+
+const (
+	tileFlipH = 1 << iota // flipped horizontally
+	tileFlipV             // flipped vertically
+)
+
+type tilemap struct {
+	name      string
+	cols      int
+	rows      int
+	tileW     float64
+	tileH     float64
+	tiles     []int
+	flips     []uint8    // tileFlipH and tileFlipV of tiles, nil if no tile is flipped
+	tilesets  []*tileset // sorted by firstGid
+	x, y      float64    // top-left corner in stage coordinates
+	visible   bool
+	z         int64
+	collLayer int64
+	collMask  int64
+
+	// only accessed in main thread
+	cells  map[int]*engine.Sprite   // index of tile => proxy, for tiles in the view
+	free   []*engine.Sprite         // hidden proxies to reuse
+	bodies map[int][]*engine.Sprite // row => colliders of solid tiles
+	view   [4]int                   // col0, row0, col1, row1 of tiles in the view
+}
+
+func (p *tilemap) tilesetOf(gid int) *tileset {
+	tss := p.tilesets
+	i := sort.Search(len(tss), func(i int) bool {
+		return tss[i].firstGid > gid
+	})
+	if i == 0 {
+		return nil
+	}
+	return tss[i-1]
+}
+
+// isValid reports whether gid is 0 or a tile of the tilesets.
+func (p *tilemap) isValid(gid int) bool {
+	if gid == 0 {
+		return true
+	}
+	ts := p.tilesetOf(gid)
+	return ts != nil && ts.has(gid)
+}
+
+func (p *tilemap) isSolid(gid int) bool {
+	if ts := p.tilesetOf(gid); ts != nil {
+		return ts.solid[gid]
+	}
+	return false
+}
+
+// cellAt returns the index of the tile at (x, y) in stage coordinates.
+func (p *tilemap) cellAt(x, y float64) (int, bool) {
+	col := int(math.Floor((x - p.x) / p.tileW))
+	row := int(math.Floor((p.y - y) / p.tileH))
+	if col < 0 || row < 0 || col >= p.cols || row >= p.rows {
+		return 0, false
+	}
+	return row*p.cols + col, true
+}
+
+// cellCenter returns the center of a tile in stage coordinates.
+func (p *tilemap) cellCenter(col, row int) (x, y float64) {
+	return p.x + (float64(col)+0.5)*p.tileW, p.y - (float64(row)+0.5)*p.tileH
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *Game) loadTilemaps(confs []*tilemapConfig) (err error) {
+	var tms []*tilemap
+	for _, conf := range confs {
+		var layers []*tilemap
+		if conf.Path != "" {
+			layers, err = loadTiledMap(p.fs, conf.Path)
+		} else {
+			layers, err = newTilemap(conf)
+		}
+		if err != nil {
+			return
+		}
+		for _, tm := range layers {
+			tm.x += conf.X - float64(p.worldWidth_)/2
+			tm.y = float64(p.worldHeight_)/2 - conf.Y - tm.y
+			if conf.Visible != nil {
+				tm.visible = *conf.Visible
+			}
+			tm.collLayer = parseLayerMaskValue(conf.CollisionLayer)
+			tm.collMask = parseLayerMaskValue(conf.CollisionMask)
+		}
+		tms = append(tms, layers...)
+	}
+	for _, tm := range tms {
+		for _, ts := range tm.tilesets {
+			if ts.count == 0 {
+				ts.count = ts.countBy(getCustomeAssetSize(ts.path))
+			}
+		}
+		for _, gid := range tm.tiles {
+			if !tm.isValid(gid) {
+				return fmt.Errorf("tilemap %s: invalid tile %d", tm.name, gid)
+			}
+		}
+	}
+	for i, tm := range tms {
+		tm.z = int64(i - len(tms)) // above the backdrop and under sprites
+		tm.cells = make(map[int]*engine.Sprite)
+		tm.bodies = make(map[int][]*engine.Sprite)
+		tm.view = [4]int{0, 0, -1, -1}
+	}
+	engine.WaitMainThread(func() {
+		for _, tm := range tms {
+			for row := 0; row < tm.rows; row++ {
+				tm.syncBuildRow(row)
+			}
+		}
+	})
+	p.tilemaps = tms
+	return
+}
+
+// unloadTilemaps removes all tilemap layers.
+func (p *Game) unloadTilemaps() {
+	tms := p.tilemaps
+	p.tilemaps = nil
+	engine.WaitMainThread(func() {
+		for _, tm := range tms {
+			tm.syncDestroy()
+		}
+	})
+}
+
+func newTilemap(conf *tilemapConfig) ([]*tilemap, error) {
+	tsc := conf.Tileset
+	if tsc == nil || tsc.Path == "" {
+		return nil, fmt.Errorf("tilemap %s: tileset is required", conf.Name)
+	}
+	if tsc.TileWidth <= 0 || tsc.TileHeight <= 0 || tsc.Columns <= 0 {
+		return nil, fmt.Errorf("tilemap %s: invalid tileset %s", conf.Name, tsc.Path)
+	}
+	if n := conf.Width * conf.Height; n <= 0 || len(conf.Data) != n {
+		return nil, fmt.Errorf("tilemap %s: want %d x %d tiles, got %d", conf.Name, conf.Width, conf.Height, len(conf.Data))
+	}
+	ts := &tileset{
+		path: tsc.Path, firstGid: 1, tileW: tsc.TileWidth, tileH: tsc.TileHeight,
+		columns: tsc.Columns, margin: tsc.Margin, spacing: tsc.Spacing,
+		solid: make(map[int]bool, len(tsc.Solid)),
+	}
+	for _, gid := range tsc.Solid {
+		ts.solid[gid] = true
+	}
+	tm := &tilemap{
+		name: conf.Name, cols: conf.Width, rows: conf.Height,
+		tileW: float64(ts.tileW), tileH: float64(ts.tileH),
+		tiles: conf.Data, tilesets: []*tileset{ts}, visible: true,
+	}
+	if conf.TileWidth > 0 && conf.TileHeight > 0 {
+		tm.tileW, tm.tileH = float64(conf.TileWidth), float64(conf.TileHeight)
+	}
+	return []*tilemap{tm}, nil
+}
+
+// -------------------------------------------------------------------------------------
+// Tiled map files, see https://doc.mapeditor.org/en/stable/reference/json-map-format/.
+// Only orthogonal and finite maps with CSV (not compressed) tile layers are
+// supported. A tile is solid if it has a "collision" or "solid" property of
+// true, or collision shapes. Tiles can be flipped horizontally or vertically,
+// but not diagonally (rotated).
+
+const (
+	tiledFlipH   = 0x80000000
+	tiledFlipV   = 0x40000000
+	tiledFlipD   = 0x20000000 // diagonally, ie. rotated
+	tiledFlipHex = 0x10000000 // rotated 120 degrees, hexagonal maps only
+	tiledGidMask = 0x0fffffff // clear flipping flags
+)
+
+type tiledMap struct {
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	TileWidth   int             `json:"tilewidth"`
+	TileHeight  int             `json:"tileheight"`
+	Orientation string          `json:"orientation"`
+	Infinite    bool            `json:"infinite"`
+	Layers      []*tiledLayer   `json:"layers"`
+	Tilesets    []*tiledTileset `json:"tilesets"`
+}
+
+type tiledLayer struct {
+	Type     string          `json:"type"`
+	Name     string          `json:"name"`
+	Width    int             `json:"width"`
+	Height   int             `json:"height"`
+	Data     json.RawMessage `json:"data"`
+	Encoding string          `json:"encoding"`
+	Visible  bool            `json:"visible"`
+	OffsetX  float64         `json:"offsetx"`
+	OffsetY  float64         `json:"offsety"`
+	Layers   []*tiledLayer   `json:"layers"` // layers of a group
+}
+
+type tiledTileset struct {
+	FirstGid   int          `json:"firstgid"`
+	Source     string       `json:"source"` // external tileset (.tsj)
+	Image      string       `json:"image"`
+	ImageWidth int          `json:"imagewidth"`
+	TileWidth  int          `json:"tilewidth"`
+	TileHeight int          `json:"tileheight"`
+	Columns    int          `json:"columns"`
+	TileCount  int          `json:"tilecount"`
+	Margin     int          `json:"margin"`
+	Spacing    int          `json:"spacing"`
+	Tiles      []*tiledTile `json:"tiles"`
+}
+
+type tiledTile struct {
+	ID          int              `json:"id"`
+	Properties  []*tiledProperty `json:"properties"`
+	ObjectGroup json.RawMessage  `json:"objectgroup"` // collision shapes
+}
+
+type tiledProperty struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+func (p *tiledTile) isSolid() bool {
+	for _, prop := range p.Properties {
+		if prop.Name == "collision" || prop.Name == "solid" {
+			return prop.Value == true
+		}
+	}
+	return p.ObjectGroup != nil
+}
+
+func loadTiledMap(fs spxfs.Dir, file string) (tms []*tilemap, err error) {
+	var m tiledMap
+	if err = loadJson(&m, fs, file); err != nil {
+		return
+	}
+	if m.Orientation != "orthogonal" || m.Infinite {
+		return nil, fmt.Errorf("%s: only orthogonal and finite maps are supported", file)
+	}
+	dir := path.Dir(file)
+	tss := make([]*tileset, 0, len(m.Tilesets))
+	for _, v := range m.Tilesets {
+		ts, err := loadTiledTileset(fs, dir, v)
+		if err != nil {
+			return nil, err
+		}
+		tss = append(tss, ts)
+	}
+	sort.Slice(tss, func(i, j int) bool {
+		return tss[i].firstGid < tss[j].firstGid
+	})
+	err = appendTiledLayers(&tms, m.Layers, file, 0, 0, func(l *tiledLayer, x, y float64) *tilemap {
+		return &tilemap{
+			name: l.Name, cols: l.Width, rows: l.Height,
+			tileW: float64(m.TileWidth), tileH: float64(m.TileHeight),
+			tilesets: tss, x: x, y: y, visible: l.Visible,
+		}
+	})
+	return
+}
+
+func appendTiledLayers(
+	tms *[]*tilemap, layers []*tiledLayer, file string, x, y float64,
+	newLayer func(l *tiledLayer, x, y float64) *tilemap) error {
+	for _, l := range layers {
+		switch l.Type {
+		case "tilelayer":
+			if l.Encoding != "" && l.Encoding != "csv" {
+				return fmt.Errorf("%s: layer %s: unsupported encoding %s", file, l.Name, l.Encoding)
+			}
+			var data []uint32
+			if err := json.Unmarshal(l.Data, &data); err != nil {
+				return fmt.Errorf("%s: layer %s: %w", file, l.Name, err)
+			}
+			if len(data) != l.Width*l.Height {
+				return fmt.Errorf("%s: layer %s: want %d x %d tiles, got %d", file, l.Name, l.Width, l.Height, len(data))
+			}
+			tm := newLayer(l, x+l.OffsetX, y+l.OffsetY)
+			tm.tiles = make([]int, len(data))
+			rotated := 0
+			for i, gid := range data {
+				tm.tiles[i] = int(gid & tiledGidMask)
+				if flip := tiledFlip(gid); flip != 0 {
+					if tm.flips == nil {
+						tm.flips = make([]uint8, len(data))
+					}
+					tm.flips[i] = flip
+				}
+				if gid&(tiledFlipD|tiledFlipHex) != 0 {
+					rotated++
+				}
+			}
+			if rotated > 0 {
+				log.Printf("%s: layer %s: %d rotated tiles are not supported, they are shown unrotated\n", file, l.Name, rotated)
+			}
+			*tms = append(*tms, tm)
+		case "group":
+			if err := appendTiledLayers(tms, l.Layers, file, x+l.OffsetX, y+l.OffsetY, newLayer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tiledFlip converts Tiled flags of horizontal and vertical flipping of gid.
+func tiledFlip(gid uint32) (flip uint8) {
+	if gid&tiledFlipH != 0 {
+		flip |= tileFlipH
+	}
+	if gid&tiledFlipV != 0 {
+		flip |= tileFlipV
+	}
+	return
+}
+
+func loadTiledTileset(fs spxfs.Dir, dir string, v *tiledTileset) (*tileset, error) {
+	firstGid := v.FirstGid
+	if v.Source != "" {
+		file := path.Join(dir, v.Source)
+		v = new(tiledTileset)
+		if err := loadJson(v, fs, file); err != nil {
+			return nil, err
+		}
+		dir = path.Dir(file)
+	}
+	if v.Image == "" {
+		return nil, fmt.Errorf("tileset of gid %d: image collections are not supported", firstGid)
+	}
+	ts := &tileset{
+		path: path.Join(dir, v.Image), firstGid: firstGid, tileW: v.TileWidth, tileH: v.TileHeight,
+		columns: v.Columns, margin: v.Margin, spacing: v.Spacing, count: v.TileCount,
+		solid: make(map[int]bool),
+	}
+	if ts.columns <= 0 && ts.tileW > 0 {
+		ts.columns = (v.ImageWidth - 2*v.Margin + v.Spacing) / (ts.tileW + v.Spacing)
+	}
+	if ts.columns <= 0 || ts.tileH <= 0 {
+		return nil, fmt.Errorf("tileset %s: invalid tile size", ts.path)
+	}
+	for _, t := range v.Tiles {
+		if t.isSolid() {
+			ts.solid[firstGid+t.ID] = true
+		}
+	}
+	return ts, nil
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *Game) findTilemap(name string) *tilemap {
+	for _, tm := range p.tilemaps {
+		if tm.name == name {
+			return tm
+		}
+	}
+	log.Println("tilemap not found:", name)
+	return nil
+}
+
+func (p *Game) firstTilemap() *tilemap {
+	if len(p.tilemaps) == 0 {
+		log.Println("no tilemap")
+		return nil
+	}
+	return p.tilemaps[0]
+}
+
+// TileAt__0 returns the tile at (x, y) of the first tilemap layer, 0 means
+// empty or out of the tilemap.
+func (p *Game) TileAt__0(x, y float64) int {
+	return p.tileAt(p.firstTilemap(), x, y)
+}
+
+// TileAt__1 returns the tile at (x, y) of the specified tilemap layer.
+func (p *Game) TileAt__1(layer string, x, y float64) int {
+	return p.tileAt(p.findTilemap(layer), x, y)
+}
+
+func (p *Game) tileAt(tm *tilemap, x, y float64) int {
+	if tm == nil {
+		return 0
+	}
+	if i, ok := tm.cellAt(x, y); ok {
+		return tm.tiles[i]
+	}
+	return 0
+}
+
+// SetTile__0 sets the tile at (x, y) of the first tilemap layer, 0 means to
+// clear it.
+func (p *Game) SetTile__0(x, y float64, tile int) {
+	p.setTile(p.firstTilemap(), x, y, tile)
+}
+
+// SetTile__1 sets the tile at (x, y) of the specified tilemap layer.
+func (p *Game) SetTile__1(layer string, x, y float64, tile int) {
+	p.setTile(p.findTilemap(layer), x, y, tile)
+}
+
+func (p *Game) setTile(tm *tilemap, x, y float64, tile int) {
+	if debugInstr {
+		log.Println("SetTile", x, y, tile)
+	}
+	if tm == nil {
+		return
+	}
+	if !tm.isValid(tile) {
+		log.Println("SetTile: invalid tile", tile)
+		return
+	}
+	i, ok := tm.cellAt(x, y)
+	if !ok || tm.tiles[i] == tile {
+		return
+	}
+	old := tm.tiles[i]
+	tm.tiles[i] = tile
+	if tm.flips != nil {
+		tm.flips[i] = 0
+	}
+	engine.WaitMainThread(func() {
+		col, row := i%tm.cols, i/tm.cols
+		if v := tm.view; col >= v[0] && col <= v[2] && row >= v[1] && row <= v[3] {
+			tm.syncShowCell(i)
+		}
+		if tm.isSolid(old) != tm.isSolid(tile) {
+			tm.syncBuildRow(row)
+		}
+	})
+}
+
+// -------------------------------------------------------------------------------------
+
+func (p *Game) syncUpdateTilemaps() {
+	if len(p.tilemaps) == 0 {
+		return
+	}
+	pos := engine.SyncGetCameraPosition()
+	zoom := engine.SyncGetCameraZoom()
+	if zoom.X <= 0 || zoom.Y <= 0 {
+		return
+	}
+	hw := float64(p.windowWidth_) * p.windowScale / zoom.X / 2
+	hh := float64(p.windowHeight_) * p.windowScale / zoom.Y / 2
+	for _, tm := range p.tilemaps {
+		tm.syncUpdateView(pos.X-hw, pos.Y-hh, pos.X+hw, pos.Y+hh)
+	}
+}
+
+// syncUpdateView shows tiles in the view (in stage coordinates) and hides the
+// others.
+func (p *tilemap) syncUpdateView(minX, minY, maxX, maxY float64) {
+	view := [4]int{0, 0, -1, -1}
+	if p.visible {
+		view = [4]int{
+			max(int(math.Floor((minX-p.x)/p.tileW)), 0),
+			max(int(math.Floor((p.y-maxY)/p.tileH)), 0),
+			min(int(math.Floor((maxX-p.x)/p.tileW)), p.cols-1),
+			min(int(math.Floor((p.y-minY)/p.tileH)), p.rows-1),
+		}
+	}
+	if view == p.view {
+		return
+	}
+	p.view = view
+	for i, proxy := range p.cells {
+		col, row := i%p.cols, i/p.cols
+		if col < view[0] || col > view[2] || row < view[1] || row > view[3] {
+			proxy.SetVisible(false)
+			p.free = append(p.free, proxy)
+			delete(p.cells, i)
+		}
+	}
+	for row := view[1]; row <= view[3]; row++ {
+		for col := view[0]; col <= view[2]; col++ {
+			if i := row*p.cols + col; p.cells[i] == nil {
+				p.syncShowCell(i)
+			}
+		}
+	}
+}
+
+// syncShowCell updates the proxy of a tile in the view.
+func (p *tilemap) syncShowCell(i int) {
+	proxy := p.cells[i]
+	gid := p.tiles[i]
+	var region mathf.Rect2
+	var ok bool
+	ts := p.tilesetOf(gid)
+	if ts != nil {
+		region, ok = ts.region(gid)
+	}
+	if !ok {
+		if proxy != nil {
+			proxy.SetVisible(false)
+			p.free = append(p.free, proxy)
+			delete(p.cells, i)
+		}
+		return
+	}
+	if proxy == nil {
+		if n := len(p.free); n > 0 {
+			proxy = p.free[n-1]
+			p.free = p.free[:n-1]
+		} else {
+			proxy = engine.SyncNewSprite(p)
+			proxy.DisablePhysic()
+			proxy.SetZIndex(p.z)
+		}
+		p.cells[i] = proxy
+	}
+	renderScale := mathf.NewVec2(p.tileW/float64(ts.tileW), p.tileH/float64(ts.tileH))
+	proxy.UpdateTextureAltas(ts.path, region, renderScale)
+	var flip uint8
+	if p.flips != nil {
+		flip = p.flips[i]
+	}
+	proxy.SetAnimFlipH(flip&tileFlipH != 0)
+	proxy.SetAnimFlipV(flip&tileFlipV != 0)
+	x, y := p.cellCenter(i%p.cols, i/p.cols)
+	proxy.UpdateTransform(x, y, 0, 1, true)
+	proxy.SetVisible(true)
+}
+
+// syncBuildRow creates a collider for each run of solid tiles in a row.
+func (p *tilemap) syncBuildRow(row int) {
+	for _, body := range p.bodies[row] {
+		body.Destroy()
+	}
+	delete(p.bodies, row)
+	if p.collLayer == 0 && p.collMask == 0 {
+		return
+	}
+	tiles := p.tiles[row*p.cols : (row+1)*p.cols]
+	for col := 0; col < p.cols; {
+		if !p.isSolid(tiles[col]) {
+			col++
+			continue
+		}
+		from := col
+		for col < p.cols && p.isSolid(tiles[col]) {
+			col++
+		}
+		x0, y := p.cellCenter(from, row)
+		x1, _ := p.cellCenter(col-1, row)
+		body := engine.SyncNewSprite(p)
+		body.SetTriggerEnabled(false)
+		body.SetTriggerLayer(0)
+		body.SetTriggerMask(0)
+		body.SetCollisionLayer(p.collLayer)
+		body.SetCollisionMask(p.collMask)
+		body.SetCollisionEnabled(true)
+		body.SetColliderRect(mathf.NewVec2(0, 0), mathf.NewVec2(float64(col-from)*p.tileW, p.tileH))
+		body.UpdateTransform((x0+x1)/2, y, 0, 1, true)
+		p.bodies[row] = append(p.bodies[row], body)
+	}
+}
+
+func (p *tilemap) syncDestroy() {
+	for _, proxy := range p.cells {
+		proxy.Destroy()
+	}
+	for _, proxy := range p.free {
+		proxy.Destroy()
+	}
+	for _, bodies := range p.bodies {
+		for _, body := range bodies {
+			body.Destroy()
+		}
+	}
+	p.cells, p.free, p.bodies = nil, nil, nil
+}
+
+// -------------------------------------------------------------------------------------
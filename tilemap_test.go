@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"path"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/realdream-ai/mathf"
+
+	"github.com/goplus/spx/v2/fs/embedfs"
+)
+
+const tiledTestTileset = `{
+	"firstgid": 1, "image": "tiles.png", "imagewidth": 64, "tilewidth": 16, "tileheight": 16, "tilecount": 8,
+	"tiles": [
+		{"id": 0, "properties": [{"name": "solid", "value": false}]},
+		{"id": 1, "properties": [{"name": "collision", "value": true}]},
+		{"id": 2, "objectgroup": {"objects": []}}
+	]
+}`
+
+func TestLoadTiledMap(t *testing.T) {
+	type layer struct {
+		name    string
+		x, y    float64
+		visible bool
+		tiles   []int
+		flips   []uint8
+	}
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   []layer
+		wantTs []tileset // without solid
+		solid  []map[int]bool
+		err    string
+	}{
+		{"layers", map[string]string{
+			"map.tmj": `{"width": 2, "height": 2, "tilewidth": 16, "tileheight": 16, "orientation": "orthogonal",
+				"tilesets": [` + tiledTestTileset + `],
+				"layers": [
+					{"type": "tilelayer", "name": "ground", "width": 2, "height": 2, "visible": true,
+						"data": [1, 2147483650, 0, 1073741827]},
+					{"type": "objectgroup", "name": "objects"},
+					{"type": "group", "offsetx": 10, "offsety": 20, "layers": [
+						{"type": "tilelayer", "name": "top", "width": 2, "height": 2, "offsetx": 5,
+							"data": [0, 0, 536870913, 0]}
+					]}
+				]}`,
+		}, []layer{
+			{"ground", 0, 0, true, []int{1, 2, 0, 3}, []uint8{0, tileFlipH, 0, tileFlipV}},
+			{"top", 15, 20, false, []int{0, 0, 1, 0}, nil},
+		}, []tileset{
+			{path: "tiles.png", firstGid: 1, tileW: 16, tileH: 16, columns: 4, count: 8},
+		}, []map[int]bool{{2: true, 3: true}}, ""},
+		{"external tilesets", map[string]string{
+			"maps/map.tmj": `{"width": 1, "height": 1, "tilewidth": 8, "tileheight": 8, "orientation": "orthogonal",
+				"tilesets": [{"firstgid": 9, "source": "ts/b.tsj"}, ` + tiledTestTileset + `],
+				"layers": [{"type": "tilelayer", "name": "a", "width": 1, "height": 1, "data": [9]}]}`,
+			"maps/ts/b.tsj": `{"image": "b.png", "tilewidth": 8, "tileheight": 8, "columns": 2, "margin": 1, "spacing": 2,
+				"tiles": [{"id": 1, "properties": [{"name": "solid", "value": true}]}]}`,
+		}, []layer{
+			{"a", 0, 0, false, []int{9}, nil},
+		}, []tileset{
+			{path: "maps/tiles.png", firstGid: 1, tileW: 16, tileH: 16, columns: 4, count: 8},
+			{path: "maps/ts/b.png", firstGid: 9, tileW: 8, tileH: 8, columns: 2, margin: 1, spacing: 2},
+		}, []map[int]bool{{2: true, 3: true}, {10: true}}, ""},
+		{"isometric", map[string]string{
+			"map.tmj": `{"orientation": "isometric"}`,
+		}, nil, nil, nil, "map.tmj: only orthogonal and finite maps are supported"},
+		{"infinite", map[string]string{
+			"map.tmj": `{"orientation": "orthogonal", "infinite": true}`,
+		}, nil, nil, nil, "map.tmj: only orthogonal and finite maps are supported"},
+		{"base64", map[string]string{
+			"map.tmj": `{"orientation": "orthogonal", "layers": [
+				{"type": "tilelayer", "name": "a", "width": 1, "height": 1, "encoding": "base64", "data": "AQAAAA=="}]}`,
+		}, nil, nil, nil, "map.tmj: layer a: unsupported encoding base64"},
+		{"size mismatch", map[string]string{
+			"map.tmj": `{"orientation": "orthogonal", "layers": [
+				{"type": "tilelayer", "name": "a", "width": 2, "height": 1, "data": [1]}]}`,
+		}, nil, nil, nil, "map.tmj: layer a: want 2 x 1 tiles, got 1"},
+		{"image collection", map[string]string{
+			"map.tmj": `{"orientation": "orthogonal", "tilesets": [{"firstgid": 3, "tiles": []}]}`,
+		}, nil, nil, nil, "tileset of gid 3: image collections are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := make(fstest.MapFS, len(tt.files))
+			file := ""
+			for name, data := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(data)}
+				if path.Ext(name) == ".tmj" {
+					file = name
+				}
+			}
+			dir, err := embedfs.New(fsys, ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tms, err := loadTiledMap(dir, file)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []layer
+			for _, tm := range tms {
+				got = append(got, layer{tm.name, tm.x, tm.y, tm.visible, tm.tiles, tm.flips})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got layers %+v, want %+v", got, tt.want)
+			}
+			var gotTs []tileset
+			var solid []map[int]bool
+			for _, ts := range tms[0].tilesets {
+				solid = append(solid, ts.solid)
+				v := *ts
+				v.solid = nil
+				gotTs = append(gotTs, v)
+			}
+			if !reflect.DeepEqual(gotTs, tt.wantTs) || !reflect.DeepEqual(solid, tt.solid) {
+				t.Errorf("got tilesets %+v %v, want %+v %v", gotTs, solid, tt.wantTs, tt.solid)
+			}
+		})
+	}
+}
+
+func TestTilesetRegion(t *testing.T) {
+	ts := &tileset{firstGid: 5, tileW: 16, tileH: 8, columns: 3, margin: 1, spacing: 2, count: 6}
+	tests := []struct {
+		gid  int
+		want [4]float64
+		ok   bool
+	}{
+		{4, [4]float64{}, false},
+		{5, [4]float64{1, 1, 16, 8}, true},
+		{7, [4]float64{37, 1, 16, 8}, true},
+		{9, [4]float64{19, 11, 16, 8}, true},
+		{11, [4]float64{}, false},
+	}
+	for _, tt := range tests {
+		r, ok := ts.region(tt.gid)
+		got := [4]float64{r.Position.X, r.Position.Y, r.Size.X, r.Size.Y}
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("region(%d) = %v, %v, want %v, %v", tt.gid, got, ok, tt.want, tt.ok)
+		}
+	}
+	if n := ts.countBy(mathf.NewVec2(52, 31)); n != 9 {
+		t.Errorf("countBy = %d, want 9", n)
+	}
+}
@@ -79,6 +79,20 @@ func HSBA(h, s, b, a float64) Color {
 
 // -----------------------------------------------------------------------------
 
+// tween calls step with t from 0 to 1 in secs, once per frame. The last call
+// is always step(1).
+func tween(secs float64, step func(t float64)) {
+	if secs > 0 {
+		for elapsed := 0.0; elapsed < secs; {
+			step(elapsed / secs)
+			elapsed += engine.WaitNextFrame()
+		}
+	}
+	step(1)
+}
+
+// -----------------------------------------------------------------------------
+
 func Exit__0(code int) {
 	engine.RequestExit(int64(code))
 }
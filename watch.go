@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2021 The XGo Authors (xgo.dev). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goplus/spx/v2/internal/coroutine"
+	"github.com/goplus/spx/v2/internal/engine"
+)
+
+// -------------------------------------------------------------------------------------
+// watch mode: the assets directory is polled for changes. Changed images are
+// reloaded by ReloadTexture, and the game is reloaded by Gopt_Game_Reload if a
+// JSON file changes (only string tables are reloaded if locales/*.json changes).
+// Only works when assets are loaded from the local filesystem (not packed or on
+// web).
+
+const watchInterval = 500 * time.Millisecond
+
+type eventReload struct {
+	Textures []string // changed images, relative to the assets directory
+	Index    bool     // whether a JSON file is changed
+	Locale   bool     // whether a string table (locales/*.json) is changed
+}
+
+type assetWatcher struct {
+	dir    string
+	mtimes map[string]time.Time
+}
+
+func newAssetWatcher(dir string) (*assetWatcher, error) {
+	if strings.Contains(dir, "://") {
+		return nil, fmt.Errorf("%s is packed with the engine", dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	p := &assetWatcher{dir: dir}
+	p.mtimes, _ = p.scan()
+	return p, nil
+}
+
+// scan returns modification times of all files, and files changed since the
+// last scan.
+func (p *assetWatcher) scan() (mtimes map[string]time.Time, changed []string) {
+	mtimes = make(map[string]time.Time, len(p.mtimes))
+	filepath.WalkDir(p.dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(p.dir, file)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		mtimes[rel] = info.ModTime()
+		if old, ok := p.mtimes[rel]; !ok || !old.Equal(info.ModTime()) {
+			changed = append(changed, rel)
+		}
+		return nil
+	})
+	return
+}
+
+func (p *assetWatcher) run(g eventFirer) {
+	for {
+		time.Sleep(watchInterval)
+		var mtimes map[string]time.Time
+		var changed []string
+		if mtimes, changed = p.scan(); len(changed) == 0 {
+			continue
+		}
+		ev := &eventReload{}
+		for _, file := range changed {
+			switch strings.ToLower(path.Ext(file)) {
+			case ".json":
+				data, err := os.ReadFile(filepath.Join(p.dir, file))
+				if err != nil || !json.Valid(data) {
+					log.Println("Watch: skip invalid JSON file", file) // maybe it's still being written
+					// keep the old modification time to retry it in the next poll
+					if old, ok := p.mtimes[file]; ok {
+						mtimes[file] = old
+					} else {
+						delete(mtimes, file)
+					}
+					continue
+				}
+				if strings.HasPrefix(file, "locales/") {
+					ev.Locale = true
+				} else {
+					ev.Index = true
+				}
+			case ".png", ".jpg", ".jpeg", ".svg", ".webp":
+				ev.Textures = append(ev.Textures, file)
+			}
+		}
+		p.mtimes = mtimes
+		if ev.Index || ev.Locale || len(ev.Textures) > 0 {
+			if debugLoad {
+				log.Println("==> Watch: changed", changed)
+			}
+			g.fireEvent(ev)
+		}
+	}
+}
+
+// watchAssets starts watching the assets directory, index is where index.json
+// is, see Config.Index.
+func (p *Game) watchAssets(index any) {
+	if p.assetCache != nil {
+		log.Println("Warning: --watch is ignored, assets are not in a local directory")
+		return
+	}
+	w, err := newAssetWatcher(engine.ToAssetPath(""))
+	if err != nil {
+		log.Println("Warning: --watch is ignored, assets are not in a local directory -", err)
+		return
+	}
+	p.watchIndex = index
+	go w.run(p)
+}
+
+func (p *Game) doReload(ev *eventReload) {
+	if len(ev.Textures) > 0 {
+		engine.WaitMainThread(func() {
+			for _, file := range ev.Textures {
+				getBoundsCache().remove(file)
+				engine.SyncReloadTexture(engine.ToAssetPath(file))
+			}
+		})
+	}
+	if ev.Locale {
+		p.setLanguage(p.Language())
+	}
+	if !ev.Index {
+		return
+	}
+	switch p.watchIndex.(type) {
+	case string, nil:
+	default: // io.Reader can't be read again
+		return
+	}
+
+	// keep positions of sprites, so that the reload doesn't interrupt editing
+	type spriteState struct {
+		x, y    float64
+		heading Direction
+	}
+	states := make(map[string]spriteState, len(p.sprs))
+	for name, sp := range p.sprs {
+		if spr := spriteOf(sp); spr != nil {
+			states[name] = spriteState{spr.x, spr.y, spr.direction}
+		}
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			if e == coroutine.ErrAbortThread {
+				panic(e)
+			}
+			log.Println("Reload failed:", e)
+		}
+	}()
+	if err := Gopt_Game_Reload(p.gamer_, p.watchIndex); err != nil {
+		log.Println("Reload failed:", err)
+		return
+	}
+	for name, sp := range p.sprs {
+		if st, ok := states[name]; ok {
+			spr := spriteOf(sp)
+			spr.SetXYpos(st.x, st.y)
+			spr.SetHeading(st.heading)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------